@@ -0,0 +1,119 @@
+package announce
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/logging"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const queueSize = 100
+
+// Announcer posts catches to the Slack channel configured for the catcher's
+// team. Announcements are best effort: they are dropped when the queue is
+// full and are not retried.
+type Announcer struct {
+	logger     lager.Logger
+	d          *db.DB
+	httpClient *http.Client
+	tiers      []encounter.TierConfig
+	spriteURL  string
+	events     chan models.GameEvent
+}
+
+func NewAnnouncer(logger lager.Logger, d *db.DB, httpClient *http.Client, tiers []encounter.TierConfig, spriteURL string) *Announcer {
+	return &Announcer{
+		logger:     logger,
+		d:          d,
+		httpClient: httpClient,
+		tiers:      tiers,
+		spriteURL:  spriteURL,
+		events:     make(chan models.GameEvent, queueSize),
+	}
+}
+
+func (a *Announcer) Publish(logger lager.Logger, event models.GameEvent) {
+	if event.Type != models.GameEventCatch || event.Catch == nil || event.Team == "" {
+		return
+	}
+
+	select {
+	case a.events <- event:
+	default:
+		logger.Info("announcement-dropped", lager.Data{"team": event.Team, "encounter-id": event.Catch.EncounterID})
+	}
+}
+
+func (a *Announcer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := a.logger.Session("announcer")
+	logger.Info("started")
+	defer logger.Info("complete")
+
+	close(ready)
+
+	for {
+		select {
+		case sig := <-signals:
+			logger.Info("signaled", lager.Data{"signal": sig})
+			return nil
+		case event := <-a.events:
+			a.announce(logger, event)
+		}
+	}
+}
+
+func (a *Announcer) announce(logger lager.Logger, event models.GameEvent) {
+	logger = logger.Session("announce", lager.Data{"team": event.Team, "encounter-id": event.Catch.EncounterID})
+
+	team, err := a.d.GetTeam(logger, event.Team)
+	if err != nil {
+		logger.Error("failed-to-get-team", err)
+		return
+	}
+
+	announcements := team.Announcements
+	if announcements.SlackWebhookURL == "" {
+		return
+	}
+	if !encounter.AtLeast(a.tiers, event.Catch.Pokemon.Tier, announcements.MinTier) {
+		return
+	}
+
+	err = a.post(announcements.SlackWebhookURL, NewMessage(event, a.spriteURL))
+	if err != nil {
+		logger.Error("failed-to-post-announcement", err)
+		return
+	}
+
+	logger.Info("announced", lager.Data{"pokemon": event.Catch.Pokemon.Name, "tier": event.Catch.Pokemon.Tier})
+}
+
+func (a *Announcer) post(url string, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The URL is the webhook's credential, so it is left out.
+		return logging.RedactURLError(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
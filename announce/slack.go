@@ -0,0 +1,93 @@
+package announce
+
+import (
+	"fmt"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+// Message is a Slack incoming-webhook body. Text is the fallback shown in
+// notifications; Blocks is what is rendered in the channel.
+type Message struct {
+	Text   string  `json:"text"`
+	Blocks []Block `json:"blocks"`
+}
+
+type Block struct {
+	Type      string     `json:"type"`
+	Text      *Text      `json:"text,omitempty"`
+	Accessory *Accessory `json:"accessory,omitempty"`
+	Elements  []Text     `json:"elements,omitempty"`
+}
+
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type Accessory struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// NewMessage formats a catch. spriteURL is a format string taking the
+// Pokemon's national dex number.
+func NewMessage(event models.GameEvent, spriteURL string) Message {
+	pokemon := event.Catch.Pokemon
+
+	reason := "as a streak bonus"
+	if story := event.Catch.Story; story != nil {
+		reason = fmt.Sprintf("for accepting <%s|%s>", story.URL, escape(story.Name))
+	}
+
+	text := fmt.Sprintf("%s caught a %s (%s)!", event.Username, pokemon.Name, pokemon.Tier)
+
+	section := Block{
+		Type: "section",
+		Text: &Text{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("*%s* caught a *%s* %s!", escape(event.Username), escape(pokemon.Name), reason),
+		},
+	}
+	if spriteURL != "" {
+		section.Accessory = &Accessory{
+			Type:     "image",
+			ImageURL: fmt.Sprintf(spriteURL, pokemon.Index),
+			AltText:  pokemon.Name,
+		}
+	}
+
+	context := Block{
+		Type: "context",
+		Elements: []Text{
+			{Type: "mrkdwn", Text: fmt.Sprintf("Rarity: *%s*", escape(pokemon.Tier))},
+			{Type: "mrkdwn", Text: fmt.Sprintf("Pool: %s", escape(pokemon.Pool))},
+			{Type: "mrkdwn", Text: fmt.Sprintf("Team: %s", escape(event.Team))},
+		},
+	}
+
+	return Message{
+		Text:   text,
+		Blocks: []Block{section, context},
+	}
+}
+
+// escape replaces the characters Slack treats as control sequences in
+// mrkdwn.
+func escape(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '&':
+			out = append(out, "&amp;"...)
+		case '<':
+			out = append(out, "&lt;"...)
+		case '>':
+			out = append(out, "&gt;"...)
+		default:
+			out = append(out, s[i])
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+// Regenerate with data/professor_oak.go.
+//
+//go:embed catalog.csv
+var defaultCatalog []byte
+
+type ParseError struct {
+	Line   int
+	Reason string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("invalid-catalog-row: line %d: %s", e.Line, e.Reason)
+}
+
+// Default returns the catalog compiled into the binary.
+func Default() ([]models.PokemonEntry, error) {
+	return Parse(bytes.NewReader(defaultCatalog))
+}
+
+func Load(path string) ([]models.PokemonEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse reads "index,name,weight" rows. Any malformed row fails the whole
+// catalog rather than being skipped.
+func Parse(r io.Reader) ([]models.PokemonEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+
+	entries := []models.PokemonEntry{}
+	line := 0
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, ParseError{line, err.Error()}
+		}
+
+		index, err := strconv.Atoi(row[0])
+		if err != nil {
+			return nil, ParseError{line, "index is not an integer"}
+		}
+
+		weight, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			return nil, ParseError{line, "weight is not a number"}
+		}
+
+		entries = append(entries, models.PokemonEntry{
+			Index:  index,
+			Name:   strings.Title(row[1]),
+			Weight: weight,
+		})
+	}
+
+	return entries, nil
+}
+
+// Write emits entries in the format Parse consumes.
+func Write(w io.Writer, entries []models.PokemonEntry) error {
+	writer := csv.NewWriter(w)
+
+	for _, entry := range entries {
+		err := writer.Write([]string{
+			strconv.Itoa(entry.Index),
+			entry.Name,
+			strconv.FormatFloat(entry.Weight, 'f', -1, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -1,22 +1,28 @@
 package main
 
 import (
-	"bufio"
-	"crypto/tls"
 	"database/sql"
 	"flag"
-	"fmt"
 	"math/rand"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
+	"github.com/jfmyers9/gotta-track-em-all/announce"
+	"github.com/jfmyers9/gotta-track-em-all/catalog"
+	"github.com/jfmyers9/gotta-track-em-all/config"
+	"github.com/jfmyers9/gotta-track-em-all/dashboard"
 	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/feed"
 	"github.com/jfmyers9/gotta-track-em-all/handlers"
+	"github.com/jfmyers9/gotta-track-em-all/logging"
 	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
+	"github.com/jfmyers9/gotta-track-em-all/transport"
 	"github.com/jfmyers9/gotta-track-em-all/watcher"
+	"github.com/jfmyers9/gotta-track-em-all/webhooks"
 	"github.com/pivotal-golang/lager"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/grouper"
@@ -26,40 +32,111 @@ import (
 	_ "github.com/lib/pq"
 )
 
-var pokemonCSV = flag.String(
-	"pokemonCSV",
+var configFile = flag.String(
+	"config",
 	"",
-	"path to pokemon csv",
+	"path to a YAML or JSON config file (or GTEA_CONFIG); GTEA_* environment variables and flags override it",
 )
 
-var listenAddress = flag.String(
-	"listenAddress",
-	"",
-	"Address to listen for requests on",
-)
+func loadConfig() (config.Config, error) {
+	cfg := config.Default()
 
-var dbConnectionString = flag.String(
-	"dbConnectionString",
-	"",
-	"The connection string to the postgres db",
-)
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		err := cfg.Load(path)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	err := cfg.ApplyEnv(os.LookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+
+	err = cfg.ApplyFlags(flag.CommandLine)
+	if err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.Validate()
+}
 
 func main() {
+	config.RegisterFlags(flag.CommandLine, config.Default())
 	flag.Parse()
-	logger := lager.NewLogger("gotta-track-em-all")
 
-	rand.Seed(time.Now().UnixNano())
+	logger := lager.NewLogger("gotta-track-em-all")
 
-	sink := lager.NewReconfigurableSink(lager.NewWriterSink(os.Stdout, lager.DEBUG), lager.DEBUG)
+	cfg, configErr := loadConfig()
+	minLevel, _ := logging.ParseLevel(cfg.LogLevel)
+	sink := lager.NewReconfigurableSink(logging.NewRedactingSink(lager.NewWriterSink(os.Stdout, lager.DEBUG)), minLevel)
 	logger.RegisterSink(sink)
 
-	pokemonList, err := parsePokemonCSV(*pokemonCSV)
+	if configErr != nil {
+		logger.Error("invalid-configuration", configErr)
+		os.Exit(1)
+	}
+
+	var species []models.PokemonEntry
+	var err error
+	if cfg.Catalog.PokemonCSV != "" {
+		species, err = catalog.Load(cfg.Catalog.PokemonCSV)
+	} else {
+		species, err = catalog.Default()
+	}
 	if err != nil {
 		logger.Error("failed-to-parse-pokemon", err)
 		os.Exit(1)
 	}
 
-	sqlConn, err := sql.Open("postgres", *dbConnectionString)
+	tiers := encounter.DefaultTiers
+	if cfg.Catalog.RarityTiers != "" {
+		tiers, err = encounter.LoadTiers(cfg.Catalog.RarityTiers)
+		if err != nil {
+			logger.Error("failed-to-load-rarity-tiers", err)
+			os.Exit(1)
+		}
+	}
+
+	poolConfigs := []encounter.PoolConfig{}
+	if cfg.Catalog.EncounterPools != "" {
+		poolConfigs, err = encounter.LoadPools(cfg.Catalog.EncounterPools)
+		if err != nil {
+			logger.Error("failed-to-load-encounter-pools", err)
+			os.Exit(1)
+		}
+	}
+
+	pools, err := encounter.NewPools(species, tiers, poolConfigs)
+	if err != nil {
+		logger.Error("invalid-encounter-pools", err)
+		os.Exit(1)
+	}
+
+	table, ok := pools.Get(cfg.Catalog.EncounterPool)
+	if !ok {
+		logger.Error("unknown-encounter-pool", encounter.InvalidPoolError{ID: cfg.Catalog.EncounterPool, Reason: "pool is not configured"})
+		os.Exit(1)
+	}
+	logger.Info("loaded-pokemon-catalog", lager.Data{"species": len(species), "pool": table.Pool(), "version": table.Version()})
+
+	calendar, err := streak.NewCalendar(cfg.Streaks.WorkingDays, cfg.Streaks.Holidays, cfg.Streaks.Timezone)
+	if err != nil {
+		logger.Error("invalid-streak-calendar", err)
+		os.Exit(1)
+	}
+
+	milestones, err := streak.ParseMilestones(cfg.Streaks.Milestones)
+	if err != nil {
+		logger.Error("invalid-streak-milestones", err)
+		os.Exit(1)
+	}
+
+	sqlConn, err := sql.Open("postgres", cfg.DBConnectionString)
 	if err != nil {
 		logger.Error("failed-to-construct-sql-conn", err)
 		os.Exit(1)
@@ -79,77 +156,91 @@ func main() {
 		os.Exit(1)
 	}
 
-	handler, err := handlers.NewHandler(logger, d)
+	tr := &http.Transport{
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	err = transport.Configure(tr, cfg.Tracker.CACert, cfg.Tracker.Proxy)
 	if err != nil {
-		logger.Error("failed-to-construct-handlers", err)
+		logger.Error("failed-to-configure-tracker-transport", err)
 		os.Exit(1)
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	httpClient := &http.Client{
+		Transport: tr,
+		Timeout:   cfg.Tracker.RequestTimeout,
 	}
-	httpClient := &http.Client{Transport: tr}
+	trackerClient := tracker.NewClient(httpClient, cfg.Tracker.URL)
 
-	members := grouper.Members{
-		{"api", http_server.New(*listenAddress, handler)},
-		{"watcher", watcher.NewWatcher(logger, d, httpClient, pokemonList)},
+	seed := cfg.Watcher.RandomSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
+	logger.Info("seeding-encounters", lager.Data{"seed": seed})
 
-	group := grouper.NewOrdered(os.Interrupt, members)
+	dispatcher := webhooks.NewDispatcher(logger, d, &http.Client{Timeout: cfg.Webhooks.Timeout}, webhooks.Config{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		RetryDelay:  cfg.Webhooks.RetryDelay,
+	})
 
-	monitor := ifrit.Invoke(sigmon.New(group))
+	announcer := announce.NewAnnouncer(logger, d, &http.Client{Timeout: cfg.Announcements.Timeout}, pools.Tiers(), cfg.Announcements.SpriteURL)
 
-	logger.Info("started")
+	broker := feed.NewBroker()
+	publisher := watcher.Publishers{dispatcher, announcer, broker}
 
-	err = <-monitor.Wait()
+	w := watcher.NewWatcher(logger, d, trackerClient, pools, watcher.Config{
+		Publisher:    publisher,
+		Workers:      cfg.Watcher.Workers,
+		PollInterval: cfg.Watcher.PollInterval,
+		RandomSource: rand.NewSource(seed),
+		Pool:         cfg.Catalog.EncounterPool,
+		Calendar:     calendar,
+		Milestones:   milestones,
+	})
+
+	pages, err := dashboard.New(cfg.Dashboard.SpriteURL)
 	if err != nil {
-		logger.Error("exited-with-failure", err)
+		logger.Error("failed-to-parse-dashboard", err)
 		os.Exit(1)
 	}
 
-	logger.Info("exited")
-}
-
-func parsePokemonCSV(path string) ([]*models.PokemonEntry, error) {
-	var cumWeight float64 = 0
-	pokemonList := []*models.PokemonEntry{}
-
-	file, err := os.Open(path)
+	staleAfter := time.Duration(cfg.Watcher.ReadinessIntervals) * w.PollInterval()
+	handler, err := handlers.NewHandler(logger, d, handlers.Config{
+		Watcher:           w,
+		StaleAfter:        staleAfter,
+		Calendar:          calendar,
+		Pools:             pools,
+		DefaultPool:       cfg.Catalog.EncounterPool,
+		Pages:             pages,
+		TrackerClient:     trackerClient,
+		LogLevel:          sink,
+		Publisher:         publisher,
+		Broker:            broker,
+		AdminToken:        cfg.AdminToken,
+		ChatSigningSecret: cfg.Chat.SigningSecret,
+	})
 	if err != nil {
-		return nil, err
+		logger.Error("failed-to-construct-handlers", err)
+		os.Exit(1)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
 
-	for scanner.Scan() {
-		row := strings.Split(string(scanner.Text()), ",")
-		if len(row) != 3 {
-			println("what?")
-			continue
-		}
+	members := grouper.Members{
+		{"api", http_server.New(cfg.ListenAddress, handler)},
+		{"webhooks", dispatcher},
+		{"announcer", announcer},
+		{"watcher", w},
+	}
 
-		index, err := strconv.Atoi(row[0])
-		if err != nil {
-			fmt.Println("messed up")
-			continue
-		}
+	group := grouper.NewOrdered(os.Interrupt, members)
 
-		weight, err := strconv.ParseFloat(row[2], 64)
-		if err != nil {
-			fmt.Println("messed up more")
-			continue
-		}
+	monitor := ifrit.Invoke(sigmon.New(group))
 
-		cumWeight += weight
+	logger.Info("started")
 
-		entry := models.PokemonEntry{
-			Index:  index,
-			Name:   strings.Title(row[1]),
-			Weight: cumWeight,
-		}
-		pokemonList = append(pokemonList, &entry)
+	err = <-monitor.Wait()
+	if err != nil {
+		logger.Error("exited-with-failure", err)
+		os.Exit(1)
 	}
 
-	return pokemonList, scanner.Err()
+	logger.Info("exited")
 }
@@ -7,12 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/cf_http"
 	"github.com/codegangsta/cli"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
 	"github.com/jfmyers9/gotta-track-em-all/handlers"
 	"github.com/jfmyers9/gotta-track-em-all/models"
 	"github.com/jfmyers9/gotta-track-em-all/routes"
+	"github.com/jfmyers9/gotta-track-em-all/transport"
 	"github.com/tedsuo/rata"
 )
 
@@ -21,6 +26,13 @@ func main() {
 	app.Name = "pokedex"
 	app.Usage = "catch them all!"
 
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "ca-cert", Usage: "path to a PEM bundle of additional CAs to trust", EnvVar: "POKEDEX_CA_CERT"},
+		cli.StringFlag{Name: "proxy", Usage: "url of an http proxy to use", EnvVar: "POKEDEX_PROXY"},
+		cli.StringFlag{Name: "admin-token", Usage: "token for admin commands", EnvVar: "POKEDEX_ADMIN_TOKEN"},
+		cli.BoolFlag{Name: "no-color", Usage: "disable colored output", EnvVar: "NO_COLOR"},
+	}
+
 	app.Commands = []cli.Command{
 		{
 			Name:  "register-user",
@@ -50,15 +62,85 @@ func main() {
 			},
 			Action: GetPokemon,
 		},
+		{
+			Name:  "status",
+			Usage: "show when your pokedex was last synced with tracker",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: Status,
+		},
+		{
+			Name:  "sync",
+			Usage: "sync with tracker now (all users if no username is given)",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: Sync,
+		},
+		{
+			Name:  "events",
+			Usage: "list current and upcoming events",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: ListEvents,
+		},
+		{
+			Name:  "projects",
+			Usage: "list your tracker projects and whether they earn pokemon",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: ListProjects,
+		},
+		{
+			Name:  "set-projects",
+			Usage: "only earn pokemon for acceptances in these tracker projects (empty to use your team's)",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "projects", Usage: "comma separated tracker project ids"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: SetProjects,
+		},
+		{
+			Name:  "link-chat",
+			Usage: "link a user to their chat account for /pokedex commands (empty to unlink)",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "chat-id", Usage: "chat user id, e.g. U024BE7LH"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: LinkChat,
+		},
+		{
+			Name:      "log-level",
+			Usage:     "show or change the server's log level (debug, info, error, fatal)",
+			ArgsUsage: "[level]",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: LogLevel,
+		},
+		teamCommand,
+		webhookCommand,
 	}
 
 	app.Run(os.Args)
 }
 
 func CreateUser(c *cli.Context) error {
-	url := c.String("url")
-	client := newClient(url)
-	err := client.CreateUser(c.String("u"), c.String("t"))
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.CreateUser(c.String("u"), c.String("t"))
 	if err != nil {
 		fmt.Printf("Error: %s\n", err.Error())
 	} else {
@@ -69,9 +151,13 @@ func CreateUser(c *cli.Context) error {
 }
 
 func RemoveUser(c *cli.Context) error {
-	url := c.String("url")
-	client := newClient(url)
-	err := client.RemoveUser(c.String("u"))
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.RemoveUser(c.String("u"))
 	if err != nil {
 		fmt.Printf("Error: %s\n", err.Error())
 	} else {
@@ -82,8 +168,11 @@ func RemoveUser(c *cli.Context) error {
 }
 
 func GetPokemon(c *cli.Context) error {
-	url := c.String("url")
-	client := newClient(url)
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
 
 	user, err := client.GetUser(c.String("u"))
 	if err != nil {
@@ -91,24 +180,233 @@ func GetPokemon(c *cli.Context) error {
 		return err
 	}
 
+	fmt.Printf("Streak: %d working days (longest %d)\n", user.Streak.Current, user.Streak.Longest)
 	fmt.Printf("Pokedex:\n")
-	for _, name := range user.Pokemon {
-		fmt.Printf("  %s\n", name)
+	for _, pokemon := range user.Pokemon {
+		fmt.Printf("  %s\n", formatPokemon(pokemon, !c.GlobalBool("no-color")))
 	}
 
 	return nil
 }
 
+var tierColors = map[string]string{
+	encounter.Common:    "\x1b[37m",
+	encounter.Uncommon:  "\x1b[32m",
+	encounter.Rare:      "\x1b[34m",
+	encounter.Legendary: "\x1b[33;1m",
+}
+
+func formatPokemon(pokemon models.Pokemon, color bool) string {
+	if pokemon.Tier == "" {
+		return fmt.Sprintf("%d: %s", pokemon.Index, pokemon.Name)
+	}
+
+	line := fmt.Sprintf("%d: %s (%s)", pokemon.Index, pokemon.Name, pokemon.Tier)
+	code, ok := tierColors[pokemon.Tier]
+	if !color || !ok {
+		return line
+	}
+
+	return code + line + "\x1b[0m"
+}
+
+func Status(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	status, err := client.GetUserStatus(c.String("u"))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	if status.LastSyncedAt.IsZero() {
+		fmt.Printf("Last synced: never\n")
+	} else {
+		fmt.Printf("Last synced: %s\n", status.LastSyncedAt.Format(time.RFC1123))
+	}
+
+	fmt.Printf("Consecutive failures: %d\n", status.ConsecutiveFailures)
+	if status.LastError != "" {
+		fmt.Printf("Last error: %s\n", status.LastError)
+	}
+
+	return nil
+}
+
+func Sync(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.Sync(c.String("u"))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+	} else {
+		fmt.Printf("Sync requested!\n")
+	}
+
+	return err
+}
+
+func ListEvents(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	events, err := client.ListEvents()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	if len(events) == 0 {
+		fmt.Printf("No current or upcoming events.\n")
+		return nil
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		state := "upcoming"
+		if event.ActiveAt(now) {
+			state = "active"
+		}
+
+		fmt.Printf("%s (%s)\n", event.Name, state)
+		fmt.Printf("  %s - %s\n", event.StartsAt.Format(time.RFC1123), event.EndsAt.Format(time.RFC1123))
+		fmt.Printf("  x%s boost", strconv.FormatFloat(event.Multiplier, 'f', -1, 64))
+		if len(event.Species) > 0 {
+			fmt.Printf(" for species %s", joinInts(event.Species))
+		}
+		if len(event.Tiers) > 0 {
+			fmt.Printf(" for %s pokemon", strings.Join(event.Tiers, ", "))
+		}
+		fmt.Printf("\n")
+	}
+
+	return nil
+}
+
+func ListProjects(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	projects := []models.Project{}
+	err = client.do(routes.GetUserProjects, rata.Params{"username": c.String("u")}, nil, &projects)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	for _, project := range projects {
+		marker := " "
+		if project.Allowed {
+			marker = "*"
+		}
+		fmt.Printf("%s %d %s\n", marker, project.ID, project.Name)
+	}
+
+	return nil
+}
+
+func SetProjects(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	projectIDs, err := parseProjectIDs(c.String("projects"))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.SetUserProjects, rata.Params{"username": c.String("u")}, handlers.SetProjectsRequest{
+		TrackerProjectIDs: projectIDs,
+	}, nil)
+	return printResult(err)
+}
+
+func LinkChat(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.SetChatUserID, rata.Params{"username": c.String("u")}, handlers.SetChatUserRequest{
+		ChatUserID: c.String("chat-id"),
+	}, nil)
+	return printResult(err)
+}
+
+func LogLevel(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	if len(c.Args()) > 0 {
+		err = client.do(routes.SetLogLevel, nil, handlers.LogLevelRequest{Level: c.Args()[0]}, nil)
+		return printResult(err)
+	}
+
+	level := handlers.LogLevelRequest{}
+	err = client.do(routes.GetLogLevel, nil, nil, &level)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	fmt.Printf("%s\n", level.Level)
+	return nil
+}
+
+func joinInts(ints []int) string {
+	strs := []string{}
+	for _, i := range ints {
+		strs = append(strs, strconv.Itoa(i))
+	}
+	return strings.Join(strs, ", ")
+}
+
 type client struct {
 	httpClient *http.Client
 	reqGen     *rata.RequestGenerator
+	adminToken string
 }
 
-func newClient(url string) *client {
-	return &client{
-		reqGen:     rata.NewRequestGenerator(url, routes.Routes),
-		httpClient: cf_http.NewClient(),
+func newClient(c *cli.Context) (*client, error) {
+	httpClient := cf_http.NewClient()
+
+	tr, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		tr = &http.Transport{}
+		httpClient.Transport = tr
+	}
+
+	err := transport.Configure(tr, c.GlobalString("ca-cert"), c.GlobalString("proxy"))
+	if err != nil {
+		return nil, err
 	}
+
+	return &client{
+		reqGen:     rata.NewRequestGenerator(c.String("url"), routes.Routes),
+		httpClient: httpClient,
+		adminToken: c.GlobalString("admin-token"),
+	}, nil
 }
 
 func (c *client) GetUser(username string) (*models.User, error) {
@@ -139,6 +437,34 @@ func (c *client) GetUser(username string) (*models.User, error) {
 	return &user, nil
 }
 
+func (c *client) GetUserStatus(username string) (*models.SyncStatus, error) {
+	params := rata.Params{}
+	params["username"] = username
+
+	request, err := c.reqGen.CreateRequest(routes.GetUserStatus, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("Could not get user status.")
+	}
+
+	var status models.SyncStatus
+	err = json.NewDecoder(response.Body).Decode(&status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
 func (c *client) CreateUser(username, trackerAPIToken string) error {
 	createRequest := handlers.CreateRequest{
 		Username:        username,
@@ -190,3 +516,60 @@ func (c *client) RemoveUser(username string) error {
 
 	return nil
 }
+
+func (c *client) Sync(username string) error {
+	var request *http.Request
+	var err error
+
+	if username == "" {
+		request, err = c.reqGen.CreateRequest(routes.Sync, nil, nil)
+	} else {
+		params := rata.Params{}
+		params["username"] = username
+		request, err = c.reqGen.CreateRequest(routes.SyncUser, params, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.adminToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusAccepted {
+		return errors.New("Could not request sync.")
+	}
+
+	return nil
+}
+
+func (c *client) ListEvents() ([]models.Event, error) {
+	request, err := c.reqGen.CreateRequest(routes.ListEvents, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("Could not list events.")
+	}
+
+	events := []models.Event{}
+	err = json.NewDecoder(response.Body).Decode(&events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
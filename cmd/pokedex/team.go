@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/jfmyers9/gotta-track-em-all/handlers"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/routes"
+	"github.com/tedsuo/rata"
+)
+
+var teamCommand = cli.Command{
+	Name:  "team",
+	Usage: "manage teams and view team pokedexes",
+	Subcommands: []cli.Command{
+		{
+			Name:  "create",
+			Usage: "create a team",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "projects", Usage: "comma separated tracker project ids"},
+				cli.StringFlag{Name: "pool", Usage: "encounter pool for the team"},
+				cli.StringFlag{Name: "attribution", Usage: "credit acceptances to the notification recipient or the story owners (recipient, owners)"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: CreateTeam,
+		},
+		{
+			Name:  "update",
+			Usage: "update a team's tracker projects, encounter pool or attribution; unset flags are left unchanged",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "projects", Usage: "comma separated tracker project ids"},
+				cli.StringFlag{Name: "pool", Usage: "encounter pool for the team"},
+				cli.StringFlag{Name: "attribution", Usage: "credit acceptances to the notification recipient or the story owners (recipient, owners)"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: UpdateTeam,
+		},
+		{
+			Name:  "list",
+			Usage: "list teams",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: ListTeams,
+		},
+		{
+			Name:  "delete",
+			Usage: "delete a team",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: DeleteTeam,
+		},
+		{
+			Name:  "join",
+			Usage: "join a team, leaving any current team",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: JoinTeam,
+		},
+		{
+			Name:  "leave",
+			Usage: "leave a team",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "u", Usage: "pivotal tracker username"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: LeaveTeam,
+		},
+		{
+			Name:  "dex",
+			Usage: "show every pokemon caught by the team",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: TeamPokedex,
+		},
+		{
+			Name:  "leaderboard",
+			Usage: "rank the team's members",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: TeamLeaderboard,
+		},
+		{
+			Name:  "announce",
+			Usage: "post the team's catches to a slack incoming webhook (omit --webhook to turn off)",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "n", Usage: "team name"},
+				cli.StringFlag{Name: "webhook", Usage: "slack incoming webhook url"},
+				cli.StringFlag{Name: "min-tier", Usage: "only announce pokemon of this rarity tier or rarer"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: SetTeamAnnouncements,
+		},
+	},
+}
+
+func parseProjectIDs(list string) ([]int, error) {
+	ids := []int{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project id %q", item)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func CreateTeam(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	projectIDs, err := parseProjectIDs(c.String("projects"))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.CreateTeam, nil, handlers.CreateTeamRequest{
+		Name:              c.String("n"),
+		TrackerProjectIDs: projectIDs,
+		Pool:              c.String("pool"),
+		Attribution:       c.String("attribution"),
+	}, nil)
+	return printResult(err)
+}
+
+// UpdateTeam sends only the flags that were given, so updating one field
+// leaves the others alone.
+func UpdateTeam(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	request := handlers.UpdateTeamRequest{}
+
+	if c.IsSet("projects") {
+		projectIDs, err := parseProjectIDs(c.String("projects"))
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			return err
+		}
+		request.TrackerProjectIDs = &projectIDs
+	}
+	if c.IsSet("pool") {
+		pool := c.String("pool")
+		request.Pool = &pool
+	}
+	if c.IsSet("attribution") {
+		attribution := c.String("attribution")
+		request.Attribution = &attribution
+	}
+
+	err = client.do(routes.UpdateTeam, rata.Params{"team": c.String("n")}, request, nil)
+	return printResult(err)
+}
+
+func ListTeams(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	teams := []models.Team{}
+	err = client.do(routes.ListTeams, nil, nil, &teams)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	for _, team := range teams {
+		fmt.Printf("%s\n", team.Name)
+		if team.Pool != "" {
+			fmt.Printf("  Pool: %s\n", team.Pool)
+		}
+		if len(team.TrackerProjectIDs) > 0 {
+			fmt.Printf("  Projects: %s\n", joinInts(team.TrackerProjectIDs))
+		}
+		fmt.Printf("  Credits: %s\n", team.Attribution)
+		fmt.Printf("  Members: %s\n", strings.Join(team.Members, ", "))
+	}
+
+	return nil
+}
+
+func DeleteTeam(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.DeleteTeam, rata.Params{"team": c.String("n")}, nil, nil)
+	return printResult(err)
+}
+
+func JoinTeam(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.JoinTeam, rata.Params{"team": c.String("n"), "username": c.String("u")}, nil, nil)
+	return printResult(err)
+}
+
+func LeaveTeam(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.LeaveTeam, rata.Params{"team": c.String("n"), "username": c.String("u")}, nil, nil)
+	return printResult(err)
+}
+
+func TeamPokedex(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	dex := []models.TeamPokemon{}
+	err = client.do(routes.GetTeamPokedex, rata.Params{"team": c.String("n")}, nil, &dex)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	color := !c.GlobalBool("no-color")
+	fmt.Printf("Team Pokedex (%d species):\n", len(dex))
+	for _, entry := range dex {
+		pokemon := models.Pokemon{Index: entry.Index, Name: entry.Name, Tier: entry.Tier}
+		fmt.Printf("  %s x%d - %s\n", formatPokemon(pokemon, color), entry.Count, strings.Join(entry.CaughtBy, ", "))
+	}
+
+	return nil
+}
+
+func TeamLeaderboard(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	leaderboard := []models.LeaderboardEntry{}
+	err = client.do(routes.GetTeamLeaderboard, rata.Params{"team": c.String("n")}, nil, &leaderboard)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	for i, entry := range leaderboard {
+		fmt.Printf("%2d. %s - %d unique, %d caught, %d day streak\n", i+1, entry.Username, entry.Unique, entry.Caught, entry.Streak.Current)
+	}
+
+	return nil
+}
+
+func SetTeamAnnouncements(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.SetTeamAnnouncements, rata.Params{"team": c.String("n")}, handlers.SetTeamAnnouncementsRequest{
+		SlackWebhookURL: c.String("webhook"),
+		MinTier:         c.String("min-tier"),
+	}, nil)
+	return printResult(err)
+}
+
+func printResult(err error) error {
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+	} else {
+		fmt.Printf("Success!\n")
+	}
+
+	return err
+}
+
+// do sends body as JSON to the named route and decodes a successful response
+// into result when it is non-nil.
+func (c *client) do(route string, params rata.Params, body interface{}, result interface{}) error {
+	var messageBody []byte
+	if body != nil {
+		var err error
+		messageBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	request, err := c.reqGen.CreateRequest(route, params, bytes.NewReader(messageBody))
+	if err != nil {
+		return err
+	}
+	request.ContentLength = int64(len(messageBody))
+
+	if c.adminToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Request failed with status %d.", response.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
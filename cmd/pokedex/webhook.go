@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/jfmyers9/gotta-track-em-all/handlers"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/routes"
+	"github.com/tedsuo/rata"
+)
+
+var webhookCommand = cli.Command{
+	Name:  "webhook",
+	Usage: "manage webhooks notified of catches and achievements",
+	Subcommands: []cli.Command{
+		{
+			Name:  "create",
+			Usage: "subscribe a URL to game events",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "endpoint", Usage: "URL to POST events to"},
+				cli.StringFlag{Name: "secret", Usage: "secret used to sign each payload"},
+				cli.StringFlag{Name: "events", Usage: "comma separated event types (catch, achievement, trade); all if empty"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: CreateWebhook,
+		},
+		{
+			Name:  "list",
+			Usage: "list webhooks",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: ListWebhooks,
+		},
+		{
+			Name:  "delete",
+			Usage: "delete a webhook and its delivery log",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "id", Usage: "webhook id"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: DeleteWebhook,
+		},
+		{
+			Name:  "deliveries",
+			Usage: "show a webhook's recent deliveries",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "id", Usage: "webhook id"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: ListWebhookDeliveries,
+		},
+		{
+			Name:  "retry",
+			Usage: "requeue a failed or dead delivery",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "id", Usage: "webhook id"},
+				cli.IntFlag{Name: "delivery", Usage: "delivery id"},
+				cli.StringFlag{Name: "url", Usage: "location of tracking api url"},
+			},
+			Action: RetryWebhookDelivery,
+		},
+	},
+}
+
+func CreateWebhook(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	eventTypes := []string{}
+	for _, eventType := range strings.Split(c.String("events"), ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "" {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	webhook := models.Webhook{}
+	err = client.do(routes.CreateWebhook, nil, handlers.CreateWebhookRequest{
+		URL:        c.String("endpoint"),
+		Secret:     c.String("secret"),
+		EventTypes: eventTypes,
+	}, &webhook)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	fmt.Printf("Created webhook %d.\n", webhook.ID)
+	return nil
+}
+
+func ListWebhooks(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	webhooks := []models.Webhook{}
+	err = client.do(routes.ListWebhooks, nil, nil, &webhooks)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		events := "all events"
+		if len(webhook.EventTypes) > 0 {
+			events = strings.Join(webhook.EventTypes, ", ")
+		}
+		fmt.Printf("%d %s (%s)\n", webhook.ID, webhook.URL, events)
+	}
+
+	return nil
+}
+
+func DeleteWebhook(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.DeleteWebhook, rata.Params{"id": strconv.Itoa(c.Int("id"))}, nil, nil)
+	return printResult(err)
+}
+
+func ListWebhookDeliveries(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err = client.do(routes.ListWebhookDeliveries, rata.Params{"id": strconv.Itoa(c.Int("id"))}, nil, &deliveries)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		fmt.Printf("%d %s %s - %s after %d attempt(s)", delivery.ID, delivery.CreatedAt.Format(time.RFC3339), delivery.EventType, delivery.Status, delivery.Attempts)
+		if delivery.LastError != "" {
+			fmt.Printf(": %s", delivery.LastError)
+		}
+		fmt.Printf("\n")
+	}
+
+	return nil
+}
+
+func RetryWebhookDelivery(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return err
+	}
+
+	err = client.do(routes.RetryWebhookDelivery, rata.Params{
+		"id":          strconv.Itoa(c.Int("id")),
+		"delivery_id": strconv.Itoa(c.Int("delivery")),
+	}, nil, nil)
+	return printResult(err)
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/logging"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
+	"github.com/jfmyers9/gotta-track-em-all/watcher"
+	"github.com/jfmyers9/gotta-track-em-all/webhooks"
+	"gopkg.in/yaml.v2"
+)
+
+const EnvPrefix = "GTEA_"
+
+// Config is everything the server can be configured with. It is built from
+// the defaults, then a YAML or JSON file, then GTEA_* environment variables,
+// then command line flags, each overriding the last.
+type Config struct {
+	ListenAddress      string `yaml:"listen_address"`
+	DBConnectionString string `yaml:"db_connection_string"`
+	LogLevel           string `yaml:"log_level"`
+	AdminToken         string `yaml:"admin_token"`
+
+	Catalog  CatalogConfig `yaml:"catalog"`
+	Watcher  WatcherConfig `yaml:"watcher"`
+	Tracker  TrackerConfig `yaml:"tracker"`
+	Streaks  StreakConfig  `yaml:"streaks"`
+	Webhooks WebhookConfig `yaml:"webhooks"`
+
+	Announcements AnnouncementConfig `yaml:"announcements"`
+	Chat          ChatConfig         `yaml:"chat"`
+	Dashboard     DashboardConfig    `yaml:"dashboard"`
+}
+
+type CatalogConfig struct {
+	PokemonCSV     string `yaml:"pokemon_csv"`
+	RarityTiers    string `yaml:"rarity_tiers"`
+	EncounterPools string `yaml:"encounter_pools"`
+	EncounterPool  string `yaml:"encounter_pool"`
+}
+
+type WatcherConfig struct {
+	PollInterval       time.Duration `yaml:"poll_interval"`
+	Workers            int           `yaml:"workers"`
+	RandomSeed         int64         `yaml:"random_seed"`
+	ReadinessIntervals int           `yaml:"readiness_intervals"`
+}
+
+type TrackerConfig struct {
+	URL            string        `yaml:"url"`
+	CACert         string        `yaml:"ca_cert"`
+	Proxy          string        `yaml:"proxy"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+}
+
+type WebhookConfig struct {
+	Timeout     time.Duration `yaml:"timeout"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	RetryDelay  time.Duration `yaml:"retry_delay"`
+}
+
+type AnnouncementConfig struct {
+	SpriteURL string        `yaml:"sprite_url"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+type ChatConfig struct {
+	SigningSecret string `yaml:"signing_secret"`
+}
+
+type DashboardConfig struct {
+	SpriteURL string `yaml:"sprite_url"`
+}
+
+type StreakConfig struct {
+	WorkingDays string `yaml:"working_days"`
+	Holidays    string `yaml:"holidays"`
+	Timezone    string `yaml:"timezone"`
+	Milestones  string `yaml:"milestones"`
+}
+
+func Default() Config {
+	return Config{
+		LogLevel: "info",
+		Catalog: CatalogConfig{
+			EncounterPool: encounter.DefaultPool,
+		},
+		Watcher: WatcherConfig{
+			PollInterval:       watcher.DefaultPollInterval,
+			Workers:            watcher.DefaultWorkers,
+			ReadinessIntervals: 3,
+		},
+		Tracker: TrackerConfig{
+			URL:            tracker.DefaultURL,
+			RequestTimeout: 10 * time.Second,
+		},
+		Streaks: StreakConfig{
+			WorkingDays: "Mon,Tue,Wed,Thu,Fri",
+			Timezone:    "UTC",
+			Milestones:  "5:1,10:2,20:3",
+		},
+		Webhooks: WebhookConfig{
+			Timeout:     10 * time.Second,
+			MaxAttempts: webhooks.DefaultMaxAttempts,
+			RetryDelay:  webhooks.DefaultRetryDelay,
+		},
+		Announcements: AnnouncementConfig{
+			SpriteURL: models.DefaultSpriteURL,
+			Timeout:   10 * time.Second,
+		},
+		Dashboard: DashboardConfig{
+			SpriteURL: models.DefaultSpriteURL,
+		},
+	}
+}
+
+// Load overlays the file at path onto c. JSON files are read as YAML, and
+// unknown keys are rejected so a typo does not silently fall back to a
+// default.
+func (c *Config) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file %s: %s", path, err)
+	}
+
+	err = yaml.UnmarshalStrict(data, c)
+	if err != nil {
+		return fmt.Errorf("config file %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// Setting binds a command line flag and an environment variable to a field.
+type Setting struct {
+	Flag  string
+	Env   string
+	Usage string
+	field func(c *Config) interface{}
+}
+
+var Settings = []Setting{
+	{"listenAddress", "LISTEN_ADDRESS", "Address to listen for requests on",
+		func(c *Config) interface{} { return &c.ListenAddress }},
+	{"dbConnectionString", "DB_CONNECTION_STRING", "The connection string to the postgres db",
+		func(c *Config) interface{} { return &c.DBConnectionString }},
+	{"logLevel", "LOG_LEVEL", "Minimum log level: debug, info, error or fatal (changeable at runtime via PUT /v1/admin/log-level)",
+		func(c *Config) interface{} { return &c.LogLevel }},
+	{"adminToken", "ADMIN_TOKEN", "Bearer token required for admin routes (admin routes are disabled if empty)",
+		func(c *Config) interface{} { return &c.AdminToken }},
+
+	{"pokemonCSV", "POKEMON_CSV", "path to a pokemon csv overriding the built-in catalog",
+		func(c *Config) interface{} { return &c.Catalog.PokemonCSV }},
+	{"rarityTiers", "RARITY_TIERS", "path to a JSON list of rarity tiers (defaults to common/uncommon/rare/legendary)",
+		func(c *Config) interface{} { return &c.Catalog.RarityTiers }},
+	{"encounterPools", "ENCOUNTER_POOLS", "path to a JSON list of additional encounter pools",
+		func(c *Config) interface{} { return &c.Catalog.EncounterPools }},
+	{"encounterPool", "ENCOUNTER_POOL", "id of the encounter pool catches are drawn from",
+		func(c *Config) interface{} { return &c.Catalog.EncounterPool }},
+
+	{"pollInterval", "POLL_INTERVAL", "How often each user's Tracker notifications are polled",
+		func(c *Config) interface{} { return &c.Watcher.PollInterval }},
+	{"watcherWorkers", "WATCHER_WORKERS", "Maximum number of users polled against Tracker concurrently",
+		func(c *Config) interface{} { return &c.Watcher.Workers }},
+	{"randomSeed", "RANDOM_SEED", "Seed for encounter rolls (seeded from the clock if 0)",
+		func(c *Config) interface{} { return &c.Watcher.RandomSeed }},
+	{"readinessIntervals", "READINESS_INTERVALS", "Number of poll intervals the watcher may go without completing a poll before /readyz fails",
+		func(c *Config) interface{} { return &c.Watcher.ReadinessIntervals }},
+
+	{"trackerURL", "TRACKER_URL", "Base URL of the Tracker API",
+		func(c *Config) interface{} { return &c.Tracker.URL }},
+	{"trackerCACert", "TRACKER_CA_CERT", "Path to a PEM bundle of additional CAs to trust when connecting to Tracker",
+		func(c *Config) interface{} { return &c.Tracker.CACert }},
+	{"trackerProxy", "TRACKER_PROXY", "URL of an HTTP proxy for Tracker requests (defaults to HTTP_PROXY/HTTPS_PROXY)",
+		func(c *Config) interface{} { return &c.Tracker.Proxy }},
+	{"trackerRequestTimeout", "TRACKER_REQUEST_TIMEOUT", "Timeout for each request made to Tracker",
+		func(c *Config) interface{} { return &c.Tracker.RequestTimeout }},
+
+	{"workingDays", "WORKING_DAYS", "comma separated weekdays that count towards acceptance streaks",
+		func(c *Config) interface{} { return &c.Streaks.WorkingDays }},
+	{"holidays", "HOLIDAYS", "comma separated YYYY-MM-DD days that do not count towards acceptance streaks",
+		func(c *Config) interface{} { return &c.Streaks.Holidays }},
+	{"streakTimezone", "STREAK_TIMEZONE", "time zone used to decide which day an acceptance happened on",
+		func(c *Config) interface{} { return &c.Streaks.Timezone }},
+	{"streakMilestones", "STREAK_MILESTONES", "comma separated streakDays:bonusRolls milestones",
+		func(c *Config) interface{} { return &c.Streaks.Milestones }},
+
+	{"webhookTimeout", "WEBHOOK_TIMEOUT", "Timeout for each webhook delivery attempt",
+		func(c *Config) interface{} { return &c.Webhooks.Timeout }},
+	{"webhookMaxAttempts", "WEBHOOK_MAX_ATTEMPTS", "Delivery attempts before a webhook delivery is marked dead",
+		func(c *Config) interface{} { return &c.Webhooks.MaxAttempts }},
+	{"webhookRetryDelay", "WEBHOOK_RETRY_DELAY", "Delay before the first webhook retry, doubled on each further attempt",
+		func(c *Config) interface{} { return &c.Webhooks.RetryDelay }},
+
+	{"spriteURL", "SPRITE_URL", "URL of a Pokemon's sprite in Slack announcements, with %d for its national dex number (empty to omit sprites)",
+		func(c *Config) interface{} { return &c.Announcements.SpriteURL }},
+	{"announcementTimeout", "ANNOUNCEMENT_TIMEOUT", "Timeout for each Slack announcement",
+		func(c *Config) interface{} { return &c.Announcements.Timeout }},
+
+	{"chatSigningSecret", "CHAT_SIGNING_SECRET", "Slack signing secret used to verify /v1/chat/command requests (chat commands are disabled when empty)",
+		func(c *Config) interface{} { return &c.Chat.SigningSecret }},
+
+	{"dashboardSpriteURL", "DASHBOARD_SPRITE_URL", "URL of a Pokemon's sprite on the dashboard, with %d for its national dex number (empty to omit sprites)",
+		func(c *Config) interface{} { return &c.Dashboard.SpriteURL }},
+}
+
+func (s Setting) get(c *Config) string {
+	switch field := s.field(c).(type) {
+	case *string:
+		return *field
+	case *int:
+		return strconv.Itoa(*field)
+	case *int64:
+		return strconv.FormatInt(*field, 10)
+	case *time.Duration:
+		return field.String()
+	default:
+		panic("unsupported setting type for " + s.Flag)
+	}
+}
+
+func (s Setting) set(c *Config, value string) error {
+	switch field := s.field(c).(type) {
+	case *string:
+		*field = value
+	case *int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field = i
+	case *int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field = i
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		*field = d
+	default:
+		panic("unsupported setting type for " + s.Flag)
+	}
+
+	return nil
+}
+
+// RegisterFlags adds a flag for every setting, showing the defaults in
+// -help. The flags only take effect through ApplyFlags.
+func RegisterFlags(flags *flag.FlagSet, defaults Config) {
+	for _, setting := range Settings {
+		flags.String(setting.Flag, setting.get(&defaults), setting.Usage+" ($"+EnvPrefix+setting.Env+")")
+	}
+}
+
+// ApplyEnv overrides c with any GTEA_* variables lookup finds.
+func (c *Config) ApplyEnv(lookup func(key string) (string, bool)) error {
+	for _, setting := range Settings {
+		value, ok := lookup(EnvPrefix + setting.Env)
+		if !ok {
+			continue
+		}
+
+		err := setting.set(c, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s%s: %s", EnvPrefix, setting.Env, err)
+		}
+	}
+
+	return nil
+}
+
+// ApplyFlags overrides c with the flags that were given explicitly, so flag
+// defaults never mask the file or environment.
+func (c *Config) ApplyFlags(flags *flag.FlagSet) error {
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	for _, setting := range Settings {
+		if !given[setting.Flag] {
+			continue
+		}
+
+		err := setting.set(c, flags.Lookup(setting.Flag).Value.String())
+		if err != nil {
+			return fmt.Errorf("invalid -%s: %s", setting.Flag, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate reports every problem with the configuration at once.
+func (c Config) Validate() error {
+	problems := []string{}
+
+	if c.ListenAddress == "" {
+		problems = append(problems, "listen_address is required")
+	}
+	if c.DBConnectionString == "" {
+		problems = append(problems, "db_connection_string is required")
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		problems = append(problems, "log_level: "+err.Error())
+	}
+	if c.Catalog.EncounterPool == "" {
+		problems = append(problems, "catalog.encounter_pool is required")
+	}
+	if c.Watcher.PollInterval <= 0 {
+		problems = append(problems, "watcher.poll_interval must be positive")
+	}
+	if c.Watcher.Workers < 1 {
+		problems = append(problems, "watcher.workers must be at least 1")
+	}
+	if c.Watcher.ReadinessIntervals < 1 {
+		problems = append(problems, "watcher.readiness_intervals must be at least 1")
+	}
+	if c.Tracker.URL == "" {
+		problems = append(problems, "tracker.url is required")
+	}
+	if c.Tracker.RequestTimeout <= 0 {
+		problems = append(problems, "tracker.request_timeout must be positive")
+	}
+	if c.Webhooks.Timeout <= 0 {
+		problems = append(problems, "webhooks.timeout must be positive")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		problems = append(problems, "webhooks.max_attempts must be at least 1")
+	}
+	if c.Webhooks.RetryDelay <= 0 {
+		problems = append(problems, "webhooks.retry_delay must be positive")
+	}
+	if c.Announcements.Timeout <= 0 {
+		problems = append(problems, "announcements.timeout must be positive")
+	}
+	if _, err := streak.NewCalendar(c.Streaks.WorkingDays, c.Streaks.Holidays, c.Streaks.Timezone); err != nil {
+		problems = append(problems, "streaks: "+err.Error())
+	}
+	if _, err := streak.ParseMilestones(c.Streaks.Milestones); err != nil {
+		problems = append(problems, "streaks.milestones: "+err.Error())
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package dashboard
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:embed templates/*.html
+var files embed.FS
+
+const layout = "templates/layout.html"
+
+// Pages are the dashboard's templates, parsed from the files compiled into
+// the binary. Each page defines "title" and "content" blocks for the layout;
+// files starting with an underscore hold partials shared by every page.
+type Pages struct {
+	pages map[string]*template.Template
+}
+
+// New parses the pages. spriteURL is a format string taking a Pokemon's
+// national dex number; sprites are left out when it is empty.
+func New(spriteURL string) (*Pages, error) {
+	funcs := template.FuncMap{
+		"inc":       func(i int) int { return i + 1 },
+		"spriteURL": func() string { return spriteURL },
+		"sprite": func(index int) string {
+			if spriteURL == "" {
+				return ""
+			}
+			return fmt.Sprintf(spriteURL, index)
+		},
+	}
+
+	base, err := template.New("layout.html").Funcs(funcs).ParseFS(files, layout, "templates/_*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := fs.Glob(files, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	pages := map[string]*template.Template{}
+	for _, name := range names {
+		if name == layout || strings.HasPrefix(path.Base(name), "_") {
+			continue
+		}
+
+		page, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = page.ParseFS(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		pages[path.Base(name)] = page
+	}
+
+	return &Pages{pages: pages}, nil
+}
+
+func (p *Pages) Render(w io.Writer, name string, data interface{}) error {
+	page, ok := p.pages[name]
+	if !ok {
+		return fmt.Errorf("unknown dashboard page %q", name)
+	}
+
+	return page.ExecuteTemplate(w, "layout.html", data)
+}
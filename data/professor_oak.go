@@ -1,43 +1,206 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
-	"strings"
+
+	"github.com/jfmyers9/gotta-track-em-all/catalog"
+	"github.com/jfmyers9/gotta-track-em-all/models"
 )
 
 var pokemon = flag.String(
 	"pokemon",
 	"",
-	"path to pokemon csv",
+	"path to the upstream pokemon csv",
+)
+
+var formula = flag.String(
+	"formula",
+	"inverse-xp",
+	"weighting formula: inverse-xp, inverse-square, flat or tiered",
+)
+
+var output = flag.String(
+	"output",
+	"",
+	"path to write the catalog to (defaults to stdout)",
+)
+
+var check = flag.String(
+	"check",
+	"",
+	"path to a committed catalog to compare against instead of writing output",
 )
 
+var formulas = map[string]func(experience float64) float64{
+	"inverse-xp": func(experience float64) float64 {
+		return 1 / experience
+	},
+	"inverse-square": func(experience float64) float64 {
+		return 1 / (experience * experience)
+	},
+	"flat": func(experience float64) float64 {
+		return 1
+	},
+	// tiered gives every species in a base experience band the same weight,
+	// chosen to land in the matching default rarity tier.
+	"tiered": func(experience float64) float64 {
+		switch {
+		case experience < 100:
+			return 1.0 / 64
+		case experience < 175:
+			return 1.0 / 140
+		case experience < 250:
+			return 1.0 / 210
+		default:
+			return 1.0 / 300
+		}
+	},
+}
+
+var requiredColumns = []string{"id", "identifier", "base_experience", "is_default"}
+
 func main() {
 	flag.Parse()
 
+	weigh, ok := formulas[*formula]
+	if !ok {
+		fail(fmt.Errorf("unknown formula %q", *formula))
+	}
+
 	file, err := os.Open(*pokemon)
 	if err != nil {
-		panic(err)
+		fail(err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		row := strings.Split(string(scanner.Text()), ",")
-		if len(row) != 3 {
-			panic("Invalid Row")
+	entries, err := generate(file, weigh)
+	if err != nil {
+		fail(err)
+	}
+
+	generated := &bytes.Buffer{}
+	err = catalog.Write(generated, entries)
+	if err != nil {
+		fail(err)
+	}
+
+	if *check != "" {
+		committed, err := catalog.Load(*check)
+		if err != nil {
+			fail(err)
 		}
 
-		experience, err := strconv.ParseFloat(row[2], 64)
+		parsed, err := catalog.Parse(bytes.NewReader(generated.Bytes()))
 		if err != nil {
-			panic("Invalid weight")
+			fail(err)
+		}
+
+		if !diff(committed, parsed) {
+			os.Exit(1)
 		}
+		return
+	}
 
-		weight := float64(1) / experience
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer out.Close()
+	}
 
-		fmt.Printf("%s,%s,%s\n", row[0], row[1], strconv.FormatFloat(weight, 'f', -1, 64))
+	_, err = generated.WriteTo(out)
+	if err != nil {
+		fail(err)
 	}
 }
+
+func generate(in io.Reader, weigh func(float64) float64) ([]models.PokemonEntry, error) {
+	reader := csv.NewReader(in)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	entries := []models.PokemonEntry{}
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		line++
+		if err != nil {
+			return nil, err
+		}
+
+		if row[columns["is_default"]] != "1" {
+			continue
+		}
+
+		index, err := strconv.Atoi(row[columns["id"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid id", line)
+		}
+
+		experience, err := strconv.ParseFloat(row[columns["base_experience"]], 64)
+		if err != nil || experience <= 0 {
+			return nil, fmt.Errorf("line %d: invalid base_experience", line)
+		}
+
+		entries = append(entries, models.PokemonEntry{
+			Index:  index,
+			Name:   row[columns["identifier"]],
+			Weight: weigh(experience),
+		})
+	}
+}
+
+// diff reports every entry that differs between the committed and generated
+// catalogs and returns whether they match.
+func diff(committed, generated []models.PokemonEntry) bool {
+	same := true
+	for i := 0; i < len(committed) || i < len(generated); i++ {
+		var want, got string
+		if i < len(committed) {
+			want = format(committed[i])
+		}
+		if i < len(generated) {
+			got = format(generated[i])
+		}
+
+		if want != got {
+			same = false
+			fmt.Printf("line %d:\n- %s\n+ %s\n", i+1, want, got)
+		}
+	}
+
+	return same
+}
+
+func format(entry models.PokemonEntry) string {
+	return fmt.Sprintf("%d,%s,%s", entry.Index, entry.Name, strconv.FormatFloat(entry.Weight, 'f', -1, 64))
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "professor_oak: %s\n", err)
+	os.Exit(1)
+}
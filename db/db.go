@@ -3,7 +3,9 @@ package db
 import (
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/jfmyers9/gotta-track-em-all/metrics"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -18,8 +20,15 @@ func NewDB(sqlConn *sql.DB) *DB {
 }
 
 func (d *DB) transact(logger lager.Logger, f func(logger lager.Logger, tx *sql.Tx) error) error {
+	start := time.Now()
+	outcome := "rollback"
+	defer func() {
+		metrics.DBTransactionDuration.WithLabelValues(outcome).Observe(metrics.Since(start))
+	}()
+
 	tx, err := d.sqlConn.Begin()
 	if err != nil {
+		outcome = "error"
 		return err
 	}
 	defer tx.Rollback()
@@ -29,5 +38,12 @@ func (d *DB) transact(logger lager.Logger, f func(logger lager.Logger, tx *sql.T
 		return err
 	}
 
-	return tx.Commit()
+	err = tx.Commit()
+	if err != nil {
+		outcome = "error"
+		return err
+	}
+
+	outcome = "commit"
+	return nil
 }
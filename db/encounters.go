@@ -0,0 +1,167 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+func insertEncounter(logger lager.Logger, tx *sql.Tx, encounter models.Encounter) (int, error) {
+	logger.Info("inserting-encounter", lager.Data{"username": encounter.Username, "pokemon": encounter.PokemonIndex})
+	var id int
+	err := tx.QueryRow(`
+	  INSERT INTO encounters(username,roll,pool_id,pool_version,pokemon_index,pokemon_name,created_at) VALUES($1,$2,$3,$4,$5,$6,$7) RETURNING id;`,
+		encounter.Username,
+		encounter.Roll,
+		encounter.Pool,
+		encounter.PoolVersion,
+		encounter.PokemonIndex,
+		encounter.PokemonName,
+		encounter.CreatedAt.UnixNano(),
+	).Scan(&id)
+	if err != nil {
+		logger.Error("failed-inserting-encounter", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// feedSequenceLock is the advisory lock held while encounters are numbered
+// for the feed. It is held until commit, so sequence numbers become visible
+// in order even though encounter IDs, taken at insert, may not.
+const feedSequenceLock = 1466467200
+
+// sequenceEncounters numbers encounters inserted in tx for the feed. Call it
+// last in the transaction, as it serializes commits.
+func sequenceEncounters(logger lager.Logger, tx *sql.Tx, encounters []models.Encounter) error {
+	if len(encounters) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock($1);`, feedSequenceLock)
+	if err != nil {
+		logger.Error("failed-to-lock-feed-sequence", err)
+		return err
+	}
+
+	for i := range encounters {
+		err := tx.QueryRow(`
+		  UPDATE encounters SET feed_sequence = nextval('encounters_feed_sequence') WHERE id = $1 RETURNING feed_sequence;`,
+			encounters[i].ID,
+		).Scan(&encounters[i].FeedSequence)
+		if err != nil {
+			logger.Error("failed-to-sequence-encounter", err, lager.Data{"encounter-id": encounters[i].ID})
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *DB) Encounters(logger lager.Logger, username string) ([]models.Encounter, error) {
+	rows, err := d.sqlConn.Query(`
+	  SELECT id,roll,pool_id,pool_version,pokemon_index,pokemon_name,created_at FROM encounters WHERE username = $1 ORDER BY id;`,
+		username,
+	)
+	if err != nil {
+		logger.Error("failed-to-fetch-encounters", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	encounters := []models.Encounter{}
+
+	for rows.Next() {
+		var encounter models.Encounter
+		var createdAt int64
+
+		err := rows.Scan(
+			&encounter.ID,
+			&encounter.Roll,
+			&encounter.Pool,
+			&encounter.PoolVersion,
+			&encounter.PokemonIndex,
+			&encounter.PokemonName,
+			&createdAt,
+		)
+		if err != nil {
+			logger.Error("failed-to-fetch-encounter", err)
+			return nil, err
+		}
+
+		encounter.Username = username
+		encounter.CreatedAt = time.Unix(0, createdAt)
+		encounters = append(encounters, encounter)
+	}
+
+	return encounters, rows.Err()
+}
+
+// CatchesAfter replays the catch log after the feed sequence number
+// afterSequence as catch events, in commit order, limited to a user or to a
+// team's current members when either is given. Pokemon tiers are not
+// recorded with encounters and are left empty.
+func (d *DB) CatchesAfter(logger lager.Logger, afterSequence int, username, team string, limit int) ([]models.GameEvent, error) {
+	return d.catches(logger, `
+	  WHERE e.feed_sequence > $1 AND ($2 = '' OR e.username = $2) AND ($3 = '' OR u.team = $3)
+	  ORDER BY e.feed_sequence LIMIT $4;`,
+		afterSequence,
+		username,
+		team,
+		limit,
+	)
+}
+
+// RecentCatches returns the latest catches, newest first, filtered like
+// CatchesAfter.
+func (d *DB) RecentCatches(logger lager.Logger, username, team string, limit int) ([]models.GameEvent, error) {
+	return d.catches(logger, `
+	  WHERE ($1 = '' OR e.username = $1) AND ($2 = '' OR u.team = $2)
+	  ORDER BY e.id DESC LIMIT $3;`,
+		username,
+		team,
+		limit,
+	)
+}
+
+func (d *DB) catches(logger lager.Logger, where string, args ...interface{}) ([]models.GameEvent, error) {
+	rows, err := d.sqlConn.Query(`
+	  SELECT e.id,COALESCE(e.feed_sequence,0),e.username,u.team,e.pool_id,e.pokemon_index,e.pokemon_name,e.created_at
+	  FROM encounters e JOIN users u ON u.username = e.username`+where,
+		args...,
+	)
+	if err != nil {
+		logger.Error("failed-to-fetch-catches", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.GameEvent{}
+
+	for rows.Next() {
+		event := models.GameEvent{Type: models.GameEventCatch, Catch: &models.Catch{}}
+		var createdAt int64
+
+		err := rows.Scan(
+			&event.Catch.EncounterID,
+			&event.Catch.FeedSequence,
+			&event.Username,
+			&event.Team,
+			&event.Catch.Pokemon.Pool,
+			&event.Catch.Pokemon.Index,
+			&event.Catch.Pokemon.Name,
+			&createdAt,
+		)
+		if err != nil {
+			logger.Error("failed-to-fetch-catch", err)
+			return nil, err
+		}
+
+		event.OccurredAt = time.Unix(0, createdAt)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+func (d *DB) CreateEvent(logger lager.Logger, event models.Event) (int, error) {
+	species, err := json.Marshal(event.Species)
+	if err != nil {
+		return 0, err
+	}
+
+	tiers, err := json.Marshal(event.Tiers)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("inserting-event", lager.Data{"name": event.Name})
+		err := tx.QueryRow(`
+		  INSERT INTO events(name,team,starts_at,ends_at,species,tiers,multiplier) VALUES($1,$2,$3,$4,$5,$6,$7) RETURNING id;`,
+			event.Name,
+			event.Team,
+			event.StartsAt.UnixNano(),
+			event.EndsAt.UnixNano(),
+			string(species),
+			string(tiers),
+			event.Multiplier,
+		).Scan(&id)
+		if err != nil {
+			logger.Error("failed-inserting-event", err)
+			return err
+		}
+		return nil
+	})
+
+	return id, err
+}
+
+// EventsEndingAfter returns every event that is running or scheduled at t,
+// ordered by start time.
+func (d *DB) EventsEndingAfter(logger lager.Logger, t time.Time) ([]models.Event, error) {
+	rows, err := d.sqlConn.Query(`
+	  SELECT id,name,team,starts_at,ends_at,species,tiers,multiplier FROM events WHERE ends_at > $1 ORDER BY starts_at;`,
+		t.UnixNano(),
+	)
+	if err != nil {
+		logger.Error("failed-to-fetch-events", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+
+	for rows.Next() {
+		var event models.Event
+		var startsAt, endsAt int64
+		var species, tiers string
+
+		err := rows.Scan(&event.ID, &event.Name, &event.Team, &startsAt, &endsAt, &species, &tiers, &event.Multiplier)
+		if err != nil {
+			logger.Error("failed-to-fetch-event", err)
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(species), &event.Species)
+		if err != nil {
+			logger.Error("failed-to-parse-event-species", err)
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(tiers), &event.Tiers)
+		if err != nil {
+			logger.Error("failed-to-parse-event-tiers", err)
+			return nil, err
+		}
+
+		event.StartsAt = time.Unix(0, startsAt)
+		event.EndsAt = time.Unix(0, endsAt)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (d *DB) ActiveEvents(logger lager.Logger, at time.Time) ([]models.Event, error) {
+	events, err := d.EventsEndingAfter(logger, at)
+	if err != nil {
+		return nil, err
+	}
+
+	active := []models.Event{}
+	for _, event := range events {
+		if event.ActiveAt(at) {
+			active = append(active, event)
+		}
+	}
+
+	return active, nil
+}
+
+func (d *DB) DeleteEvent(logger lager.Logger, id int) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		result, err := tx.Exec(`
+		  DELETE FROM events WHERE id = $1;`,
+			id,
+		)
+		if err != nil {
+			logger.Error("failed-deleting-event", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+// TeamMembers returns the members of team, or every user if team is empty.
+func (d *DB) TeamMembers(logger lager.Logger, team string) ([]*models.User, error) {
+	if team == "" {
+		return d.Users(logger)
+	}
+
+	rows, err := d.sqlConn.Query("SELECT "+userColumns+" FROM users WHERE team = $1;", team)
+	if err != nil {
+		logger.Error("failed-to-fetch-team-members", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			logger.Error("failed-to-fetch-user", err)
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// Leaderboard ranks the members of team, or every user if team is empty, by
+// unique species caught and then by total catches.
+func (d *DB) Leaderboard(logger lager.Logger, team string) ([]models.LeaderboardEntry, error) {
+	users, err := d.TeamMembers(logger, team)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.LeaderboardEntry{}
+	for _, user := range users {
+		unique := map[int]bool{}
+		for _, pokemon := range user.Pokemon {
+			unique[pokemon.Index] = true
+		}
+
+		entries = append(entries, models.LeaderboardEntry{
+			Username: user.Username,
+			Caught:   len(user.Pokemon),
+			Unique:   len(unique),
+			Streak:   user.Streak,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Unique != entries[j].Unique {
+			return entries[i].Unique > entries[j].Unique
+		}
+		if entries[i].Caught != entries[j].Caught {
+			return entries[i].Caught > entries[j].Caught
+		}
+		return entries[i].Username < entries[j].Username
+	})
+
+	return entries, nil
+}
+
+// TeamPokedex aggregates every species caught by the members of team.
+func (d *DB) TeamPokedex(logger lager.Logger, team string) ([]models.TeamPokemon, error) {
+	users, err := d.TeamMembers(logger, team)
+	if err != nil {
+		return nil, err
+	}
+
+	byIndex := map[int]*models.TeamPokemon{}
+	for _, user := range users {
+		for _, pokemon := range user.Pokemon {
+			entry, ok := byIndex[pokemon.Index]
+			if !ok {
+				entry = &models.TeamPokemon{Index: pokemon.Index, Name: pokemon.Name, Tier: pokemon.Tier}
+				byIndex[pokemon.Index] = entry
+			}
+
+			if entry.Tier == "" {
+				entry.Tier = pokemon.Tier
+			}
+			if entry.Count == 0 || entry.CaughtBy[len(entry.CaughtBy)-1] != user.Username {
+				entry.CaughtBy = append(entry.CaughtBy, user.Username)
+			}
+			entry.Count++
+		}
+	}
+
+	dex := []models.TeamPokemon{}
+	for _, entry := range byIndex {
+		dex = append(dex, *entry)
+	}
+
+	sort.Slice(dex, func(i, j int) bool { return dex[i].Index < dex[j].Index })
+	return dex, nil
+}
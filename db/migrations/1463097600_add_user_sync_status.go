@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddUserSyncStatus())
+}
+
+type addUserSyncStatus struct{}
+
+func NewAddUserSyncStatus() *addUserSyncStatus {
+	return &addUserSyncStatus{}
+}
+
+func (a *addUserSyncStatus) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(addSyncStatusColumns)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *addUserSyncStatus) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropSyncStatusColumns)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+	}
+
+	return nil
+}
+
+func (a *addUserSyncStatus) Version() int {
+	return 1463097600
+}
+
+var addSyncStatusColumns = `ALTER TABLE users
+	ADD COLUMN last_synced_at BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN last_sync_error TEXT NOT NULL DEFAULT '',
+	ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0`
+
+var dropSyncStatusColumns = `ALTER TABLE users
+	DROP COLUMN IF EXISTS last_synced_at,
+	DROP COLUMN IF EXISTS last_sync_error,
+	DROP COLUMN IF EXISTS consecutive_failures`
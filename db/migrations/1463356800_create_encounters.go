@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewCreateEncounters())
+}
+
+type createEncounters struct{}
+
+func NewCreateEncounters() *createEncounters {
+	return &createEncounters{}
+}
+
+func (c *createEncounters) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	createStatements := []string{
+		createEncountersTable,
+		createEncountersUsernameIndex,
+	}
+
+	for _, stmt := range createStatements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-creating-table", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *createEncounters) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropEncountersTable)
+	if err != nil {
+		logger.Error("failed-dropping-table", err)
+	}
+
+	return nil
+}
+
+func (c *createEncounters) Version() int {
+	return 1463356800
+}
+
+var createEncountersTable = `CREATE TABLE encounters (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(255) NOT NULL,
+	roll DOUBLE PRECISION NOT NULL,
+	pool_version VARCHAR(64) NOT NULL,
+	pokemon_index INTEGER NOT NULL,
+	pokemon_name VARCHAR(255) NOT NULL,
+	created_at BIGINT NOT NULL
+)`
+
+var createEncountersUsernameIndex = `CREATE INDEX encounters_username_idx ON encounters (username)`
+
+var dropEncountersTable = `DROP TABLE IF EXISTS encounters;`
@@ -0,0 +1,166 @@
+package migrations
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewConvertPokemonToJSON())
+}
+
+// convertPokemonToJSON rewrites the comma separated "25: Pikachu Rarity: 0.01"
+// strings stored in users.pokemon as a JSON list of structured catches. Legacy
+// catches predate rarity tiers, so their tier is left empty.
+type convertPokemonToJSON struct{}
+
+func NewConvertPokemonToJSON() *convertPokemonToJSON {
+	return &convertPokemonToJSON{}
+}
+
+type jsonPokemon struct {
+	Index int
+	Name  string
+	Tier  string
+}
+
+func (c *convertPokemonToJSON) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	return rewritePokemon(logger, sqlConn, func(pokemon string) (string, bool, error) {
+		if isJSONPokemon(pokemon) {
+			return "", false, nil
+		}
+
+		data, err := json.Marshal(parseLegacyPokemon(pokemon))
+		if err != nil {
+			return "", false, err
+		}
+		return string(data), true, nil
+	})
+}
+
+func (c *convertPokemonToJSON) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	return rewritePokemon(logger, sqlConn, func(pokemon string) (string, bool, error) {
+		if !isJSONPokemon(pokemon) {
+			return "", false, nil
+		}
+
+		catches := []jsonPokemon{}
+		err := json.Unmarshal([]byte(pokemon), &catches)
+		if err != nil {
+			return "", false, err
+		}
+
+		legacy := []string{}
+		for _, catch := range catches {
+			legacy = append(legacy, strconv.Itoa(catch.Index)+": "+catch.Name)
+		}
+		return strings.Join(legacy, ","), true, nil
+	})
+}
+
+// rewritePokemon applies convert to every user's pokemon column in a single
+// transaction, so a failure leaves every row as it was. convert reports
+// whether the row needs rewriting, which lets a rerun skip rows that are
+// already converted.
+func rewritePokemon(logger lager.Logger, sqlConn *sql.DB, convert func(string) (string, bool, error)) error {
+	tx, err := sqlConn.Begin()
+	if err != nil {
+		logger.Error("failed-starting-transaction", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT username, pokemon FROM users FOR UPDATE`)
+	if err != nil {
+		logger.Error("failed-fetching-users", err)
+		return err
+	}
+
+	converted := map[string]string{}
+	for rows.Next() {
+		var username string
+		var pokemon sql.NullString
+
+		err := rows.Scan(&username, &pokemon)
+		if err != nil {
+			rows.Close()
+			logger.Error("failed-scanning-user", err)
+			return err
+		}
+
+		rewritten, ok, err := convert(pokemon.String)
+		if err != nil {
+			rows.Close()
+			logger.Error("failed-converting-pokemon", err, lager.Data{"username": username})
+			return err
+		}
+		if ok {
+			converted[username] = rewritten
+		}
+	}
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		logger.Error("failed-fetching-users", err)
+		return err
+	}
+
+	for username, pokemon := range converted {
+		_, err := tx.Exec(`UPDATE users SET pokemon = $1 WHERE username = $2`, pokemon, username)
+		if err != nil {
+			logger.Error("failed-updating-user", err, lager.Data{"username": username})
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed-committing-transaction", err)
+		return err
+	}
+
+	return nil
+}
+
+func isJSONPokemon(pokemon string) bool {
+	return strings.HasPrefix(strings.TrimSpace(pokemon), "[")
+}
+
+func (c *convertPokemonToJSON) Version() int {
+	return 1463616000
+}
+
+func parseLegacyPokemon(pokemon string) []jsonPokemon {
+	catches := []jsonPokemon{}
+
+	for _, catch := range strings.Split(pokemon, ",") {
+		catch = strings.TrimSpace(catch)
+		if catch == "" {
+			continue
+		}
+
+		parts := strings.SplitN(catch, ": ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		index, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		name := parts[1]
+		if i := strings.Index(name, " Rarity: "); i >= 0 {
+			name = name[:i]
+		}
+
+		catches = append(catches, jsonPokemon{Index: index, Name: name})
+	}
+
+	return catches
+}
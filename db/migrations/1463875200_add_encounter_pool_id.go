@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddEncounterPoolID())
+}
+
+type addEncounterPoolID struct{}
+
+func NewAddEncounterPoolID() *addEncounterPoolID {
+	return &addEncounterPoolID{}
+}
+
+func (a *addEncounterPoolID) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(addEncounterPoolIDColumn)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *addEncounterPoolID) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropEncounterPoolIDColumn)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+	}
+
+	return nil
+}
+
+func (a *addEncounterPoolID) Version() int {
+	return 1463875200
+}
+
+var addEncounterPoolIDColumn = `ALTER TABLE encounters
+	ADD COLUMN pool_id VARCHAR(255) NOT NULL DEFAULT 'all'`
+
+var dropEncounterPoolIDColumn = `ALTER TABLE encounters
+	DROP COLUMN IF EXISTS pool_id`
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewCreateEvents())
+}
+
+type createEvents struct{}
+
+func NewCreateEvents() *createEvents {
+	return &createEvents{}
+}
+
+func (c *createEvents) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(createEventsTable)
+	if err != nil {
+		logger.Error("failed-creating-table", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *createEvents) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropEventsTable)
+	if err != nil {
+		logger.Error("failed-dropping-table", err)
+	}
+
+	return nil
+}
+
+func (c *createEvents) Version() int {
+	return 1464134400
+}
+
+var createEventsTable = `CREATE TABLE events (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	starts_at BIGINT NOT NULL,
+	ends_at BIGINT NOT NULL,
+	species TEXT NOT NULL,
+	tiers TEXT NOT NULL,
+	multiplier DOUBLE PRECISION NOT NULL
+)`
+
+var dropEventsTable = `DROP TABLE IF EXISTS events;`
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddUserStreaks())
+}
+
+type addUserStreaks struct{}
+
+func NewAddUserStreaks() *addUserStreaks {
+	return &addUserStreaks{}
+}
+
+func (a *addUserStreaks) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(addStreakColumns)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *addUserStreaks) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropStreakColumns)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+	}
+
+	return nil
+}
+
+func (a *addUserStreaks) Version() int {
+	return 1464393600
+}
+
+var addStreakColumns = `ALTER TABLE users
+	ADD COLUMN streak_current INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN streak_longest INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN streak_last_active_day VARCHAR(10) NOT NULL DEFAULT ''`
+
+var dropStreakColumns = `ALTER TABLE users
+	DROP COLUMN IF EXISTS streak_current,
+	DROP COLUMN IF EXISTS streak_longest,
+	DROP COLUMN IF EXISTS streak_last_active_day`
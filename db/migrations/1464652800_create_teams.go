@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewCreateTeams())
+}
+
+type createTeams struct{}
+
+func NewCreateTeams() *createTeams {
+	return &createTeams{}
+}
+
+func (c *createTeams) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		createTeamsTable,
+		addUserTeamColumn,
+		addEventTeamColumn,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-creating-teams", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *createTeams) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		dropEventTeamColumn,
+		dropUserTeamColumn,
+		dropTeamsTable,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-dropping-teams", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *createTeams) Version() int {
+	return 1464652800
+}
+
+var createTeamsTable = `CREATE TABLE teams (
+	name VARCHAR(255) PRIMARY KEY,
+	tracker_project_ids TEXT NOT NULL,
+	pool_id VARCHAR(255) NOT NULL DEFAULT ''
+)`
+
+var dropTeamsTable = `DROP TABLE IF EXISTS teams;`
+
+var addUserTeamColumn = `ALTER TABLE users
+	ADD COLUMN team VARCHAR(255) NOT NULL DEFAULT ''`
+
+var dropUserTeamColumn = `ALTER TABLE users
+	DROP COLUMN IF EXISTS team`
+
+var addEventTeamColumn = `ALTER TABLE events
+	ADD COLUMN team VARCHAR(255) NOT NULL DEFAULT ''`
+
+var dropEventTeamColumn = `ALTER TABLE events
+	DROP COLUMN IF EXISTS team`
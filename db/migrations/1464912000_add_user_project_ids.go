@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddUserProjectIDs())
+}
+
+type addUserProjectIDs struct{}
+
+func NewAddUserProjectIDs() *addUserProjectIDs {
+	return &addUserProjectIDs{}
+}
+
+func (a *addUserProjectIDs) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(addUserProjectIDsColumn)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *addUserProjectIDs) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropUserProjectIDsColumn)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+	}
+
+	return nil
+}
+
+func (a *addUserProjectIDs) Version() int {
+	return 1464912000
+}
+
+var addUserProjectIDsColumn = `ALTER TABLE users
+	ADD COLUMN tracker_project_ids TEXT NOT NULL DEFAULT ''`
+
+var dropUserProjectIDsColumn = `ALTER TABLE users
+	DROP COLUMN IF EXISTS tracker_project_ids`
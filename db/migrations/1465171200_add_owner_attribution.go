@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddOwnerAttribution())
+}
+
+type addOwnerAttribution struct{}
+
+func NewAddOwnerAttribution() *addOwnerAttribution {
+	return &addOwnerAttribution{}
+}
+
+func (a *addOwnerAttribution) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		addUserPersonIDColumn,
+		addTeamAttributionColumns,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-altering-table", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *addOwnerAttribution) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		dropTeamAttributionColumns,
+		dropUserPersonIDColumn,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-altering-table", err)
+		}
+	}
+
+	return nil
+}
+
+func (a *addOwnerAttribution) Version() int {
+	return 1465171200
+}
+
+var addUserPersonIDColumn = `ALTER TABLE users
+	ADD COLUMN tracker_person_id INTEGER NOT NULL DEFAULT 0`
+
+var dropUserPersonIDColumn = `ALTER TABLE users
+	DROP COLUMN IF EXISTS tracker_person_id`
+
+var addTeamAttributionColumns = `ALTER TABLE teams
+	ADD COLUMN attribution VARCHAR(32) NOT NULL DEFAULT 'recipient',
+	ADD COLUMN activity_processed_at BIGINT NOT NULL DEFAULT 0`
+
+var dropTeamAttributionColumns = `ALTER TABLE teams
+	DROP COLUMN IF EXISTS attribution,
+	DROP COLUMN IF EXISTS activity_processed_at`
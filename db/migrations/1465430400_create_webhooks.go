@@ -0,0 +1,83 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewCreateWebhooks())
+}
+
+type createWebhooks struct{}
+
+func NewCreateWebhooks() *createWebhooks {
+	return &createWebhooks{}
+}
+
+func (c *createWebhooks) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		createWebhooksTable,
+		createWebhookDeliveriesTable,
+		createWebhookDeliveriesDueIndex,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-creating-webhooks", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *createWebhooks) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		dropWebhookDeliveriesTable,
+		dropWebhooksTable,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-dropping-webhooks", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *createWebhooks) Version() int {
+	return 1465430400
+}
+
+var createWebhooksTable = `CREATE TABLE webhooks (
+	id SERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	event_types TEXT NOT NULL,
+	created_at BIGINT NOT NULL
+)`
+
+var dropWebhooksTable = `DROP TABLE IF EXISTS webhooks;`
+
+var createWebhookDeliveriesTable = `CREATE TABLE webhook_deliveries (
+	id SERIAL PRIMARY KEY,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event_type VARCHAR(32) NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_status_code INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at BIGINT NOT NULL,
+	created_at BIGINT NOT NULL,
+	delivered_at BIGINT NOT NULL DEFAULT 0
+)`
+
+var createWebhookDeliveriesDueIndex = `CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`
+
+var dropWebhookDeliveriesTable = `DROP TABLE IF EXISTS webhook_deliveries;`
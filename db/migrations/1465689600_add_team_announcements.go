@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddTeamAnnouncements())
+}
+
+type addTeamAnnouncements struct{}
+
+func NewAddTeamAnnouncements() *addTeamAnnouncements {
+	return &addTeamAnnouncements{}
+}
+
+func (a *addTeamAnnouncements) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(addTeamAnnouncementColumns)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *addTeamAnnouncements) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(dropTeamAnnouncementColumns)
+	if err != nil {
+		logger.Error("failed-altering-table", err)
+	}
+
+	return nil
+}
+
+func (a *addTeamAnnouncements) Version() int {
+	return 1465689600
+}
+
+var addTeamAnnouncementColumns = `ALTER TABLE teams
+	ADD COLUMN slack_webhook_url TEXT NOT NULL DEFAULT '',
+	ADD COLUMN slack_min_tier VARCHAR(32) NOT NULL DEFAULT ''`
+
+var dropTeamAnnouncementColumns = `ALTER TABLE teams
+	DROP COLUMN IF EXISTS slack_webhook_url,
+	DROP COLUMN IF EXISTS slack_min_tier`
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewCreateTrades())
+}
+
+type createTrades struct{}
+
+func NewCreateTrades() *createTrades {
+	return &createTrades{}
+}
+
+func (c *createTrades) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		addUserChatID,
+		createUserChatIDIndex,
+		createTradesTable,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-creating-trades", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *createTrades) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		dropTradesTable,
+		dropUserChatID,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-dropping-trades", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *createTrades) Version() int {
+	return 1465948800
+}
+
+var addUserChatID = `ALTER TABLE users
+	ADD COLUMN chat_user_id VARCHAR(255) NOT NULL DEFAULT ''`
+
+var createUserChatIDIndex = `CREATE UNIQUE INDEX users_chat_user_id ON users (chat_user_id) WHERE chat_user_id <> ''`
+
+var dropUserChatID = `ALTER TABLE users
+	DROP COLUMN IF EXISTS chat_user_id`
+
+var createTradesTable = `CREATE TABLE trades (
+	id SERIAL PRIMARY KEY,
+	proposer VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+	recipient VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+	offered_pokemon TEXT NOT NULL,
+	requested_pokemon TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	created_at BIGINT NOT NULL,
+	completed_at BIGINT NOT NULL DEFAULT 0
+)`
+
+var dropTradesTable = `DROP TABLE IF EXISTS trades;`
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewRenameNoLegendariesPool())
+}
+
+// renameNoLegendariesPool moves teams off the "no-legendaries" pool, which
+// excluded the legendary rarity tier rather than legendary species, onto the
+// same pool under its new name.
+type renameNoLegendariesPool struct{}
+
+func NewRenameNoLegendariesPool() *renameNoLegendariesPool {
+	return &renameNoLegendariesPool{}
+}
+
+func (r *renameNoLegendariesPool) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(`UPDATE teams SET pool_id = 'no-legendary-tier' WHERE pool_id = 'no-legendaries'`)
+	if err != nil {
+		logger.Error("failed-updating-teams", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *renameNoLegendariesPool) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(`UPDATE teams SET pool_id = 'no-legendaries' WHERE pool_id = 'no-legendary-tier'`)
+	if err != nil {
+		logger.Error("failed-updating-teams", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *renameNoLegendariesPool) Version() int {
+	return 1466208000
+}
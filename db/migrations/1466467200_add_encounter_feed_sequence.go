@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewAddEncounterFeedSequence())
+}
+
+type addEncounterFeedSequence struct{}
+
+func NewAddEncounterFeedSequence() *addEncounterFeedSequence {
+	return &addEncounterFeedSequence{}
+}
+
+// Up numbers existing encounters by ID, which is the order the feed used
+// before, and starts the sequence after them so resume tokens already held
+// by clients stay valid.
+func (a *addEncounterFeedSequence) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	tx, err := sqlConn.Begin()
+	if err != nil {
+		logger.Error("failed-starting-transaction", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		createEncounterFeedSequence,
+		addEncounterFeedSequenceColumn,
+		backfillEncounterFeedSequence,
+		advanceEncounterFeedSequence,
+		createEncounterFeedSequenceIndex,
+	}
+
+	for _, stmt := range statements {
+		_, err := tx.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-adding-encounter-feed-sequence", err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("failed-committing-transaction", err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *addEncounterFeedSequence) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	statements := []string{
+		dropEncounterFeedSequenceColumn,
+		dropEncounterFeedSequence,
+	}
+
+	for _, stmt := range statements {
+		_, err := sqlConn.Exec(stmt)
+		if err != nil {
+			logger.Error("failed-dropping-encounter-feed-sequence", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *addEncounterFeedSequence) Version() int {
+	return 1466467200
+}
+
+var createEncounterFeedSequence = `CREATE SEQUENCE encounters_feed_sequence`
+
+var addEncounterFeedSequenceColumn = `ALTER TABLE encounters
+	ADD COLUMN feed_sequence BIGINT`
+
+var backfillEncounterFeedSequence = `UPDATE encounters SET feed_sequence = id`
+
+var advanceEncounterFeedSequence = `SELECT setval('encounters_feed_sequence', COALESCE((SELECT MAX(id) FROM encounters), 0) + 1, false)`
+
+var createEncounterFeedSequenceIndex = `CREATE UNIQUE INDEX encounters_feed_sequence_idx ON encounters (feed_sequence)`
+
+var dropEncounterFeedSequenceColumn = `ALTER TABLE encounters
+	DROP COLUMN IF EXISTS feed_sequence`
+
+var dropEncounterFeedSequence = `DROP SEQUENCE IF EXISTS encounters_feed_sequence`
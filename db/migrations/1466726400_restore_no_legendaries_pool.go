@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func init() {
+	AppendMigration(NewRestoreNoLegendariesPool())
+}
+
+// restoreNoLegendariesPool moves the teams renamed onto "no-legendary-tier"
+// back to "no-legendaries", which now leaves out the legendary and mythical
+// species they chose it for.
+type restoreNoLegendariesPool struct{}
+
+func NewRestoreNoLegendariesPool() *restoreNoLegendariesPool {
+	return &restoreNoLegendariesPool{}
+}
+
+func (r *restoreNoLegendariesPool) Up(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(`UPDATE teams SET pool_id = 'no-legendaries' WHERE pool_id = 'no-legendary-tier'`)
+	if err != nil {
+		logger.Error("failed-updating-teams", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *restoreNoLegendariesPool) Down(logger lager.Logger, sqlConn *sql.DB) error {
+	_, err := sqlConn.Exec(`UPDATE teams SET pool_id = 'no-legendary-tier' WHERE pool_id = 'no-legendaries'`)
+	if err != nil {
+		logger.Error("failed-updating-teams", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *restoreNoLegendariesPool) Version() int {
+	return 1466726400
+}
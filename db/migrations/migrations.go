@@ -30,3 +30,15 @@ func AppendMigration(migration Migration) {
 
 	MigrationsToRun = append(MigrationsToRun, migration)
 }
+
+// LatestVersion is the version the schema is at once every migration has run.
+func LatestVersion() int {
+	latest := 0
+	for _, m := range MigrationsToRun {
+		if m.Version() > latest {
+			latest = m.Version()
+		}
+	}
+
+	return latest
+}
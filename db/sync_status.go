@@ -0,0 +1,67 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+func (d *DB) RecordSyncSuccess(logger lager.Logger, username string, syncedAt time.Time) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		  UPDATE users SET last_synced_at=$1,last_sync_error='',consecutive_failures=0 WHERE username = $2;`,
+			syncedAt.UnixNano(),
+			username,
+		)
+		if err != nil {
+			logger.Error("failed-recording-sync-success", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (d *DB) RecordSyncFailure(logger lager.Logger, username string, syncErr error) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		  UPDATE users SET last_sync_error=$1,consecutive_failures=consecutive_failures+1 WHERE username = $2;`,
+			syncErr.Error(),
+			username,
+		)
+		if err != nil {
+			logger.Error("failed-recording-sync-failure", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (d *DB) GetSyncStatus(logger lager.Logger, username string) (*models.SyncStatus, error) {
+	row := d.sqlConn.QueryRow("SELECT last_synced_at,last_sync_error,consecutive_failures FROM users WHERE username = $1;", username)
+
+	var lastSyncedAt int64
+	var lastError string
+	var consecutiveFailures int
+
+	err := row.Scan(&lastSyncedAt, &lastError, &consecutiveFailures)
+	if err == sql.ErrNoRows {
+		return nil, ResourceNotFound
+	}
+	if err != nil {
+		logger.Error("failed-to-fetch-sync-status", err)
+		return nil, err
+	}
+
+	status := &models.SyncStatus{
+		Username:            username,
+		LastError:           lastError,
+		ConsecutiveFailures: consecutiveFailures,
+	}
+	if lastSyncedAt > 0 {
+		status.LastSyncedAt = time.Unix(0, lastSyncedAt)
+	}
+
+	return status, nil
+}
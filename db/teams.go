@@ -0,0 +1,323 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+func (d *DB) CreateTeam(logger lager.Logger, team models.Team) error {
+	projectIDs, err := marshalProjectIDs(team.TrackerProjectIDs)
+	if err != nil {
+		return err
+	}
+
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("inserting-team", lager.Data{"team": team.Name})
+		_, err := tx.Exec(`
+		  INSERT INTO teams(name,tracker_project_ids,pool_id,attribution) VALUES($1,$2,$3,$4);`,
+			team.Name,
+			projectIDs,
+			team.Pool,
+			team.Attribution,
+		)
+		if err != nil {
+			logger.Error("failed-inserting-team", err)
+			return err
+		}
+		return nil
+	})
+}
+
+const teamColumns = "name,tracker_project_ids,pool_id,attribution,activity_processed_at,slack_webhook_url,slack_min_tier"
+
+func (d *DB) GetTeam(logger lager.Logger, name string) (*models.Team, error) {
+	row := d.sqlConn.QueryRow("SELECT "+teamColumns+" FROM teams WHERE name = $1;", name)
+
+	team, err := scanTeam(row)
+	if err == sql.ErrNoRows {
+		return nil, ResourceNotFound
+	}
+	if err != nil {
+		logger.Error("failed-to-fetch-team", err)
+		return nil, err
+	}
+
+	rows, err := d.sqlConn.Query("SELECT username FROM users WHERE team = $1 ORDER BY username;", name)
+	if err != nil {
+		logger.Error("failed-to-fetch-team-members", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var username string
+		err := rows.Scan(&username)
+		if err != nil {
+			logger.Error("failed-to-fetch-team-members", err)
+			return nil, err
+		}
+		team.Members = append(team.Members, username)
+	}
+
+	return team, rows.Err()
+}
+
+func (d *DB) Teams(logger lager.Logger) ([]*models.Team, error) {
+	rows, err := d.sqlConn.Query("SELECT " + teamColumns + " FROM teams ORDER BY name;")
+	if err != nil {
+		logger.Error("failed-to-fetch-teams", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []*models.Team{}
+	byName := map[string]*models.Team{}
+
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			logger.Error("failed-to-fetch-team", err)
+			return nil, err
+		}
+
+		teams = append(teams, team)
+		byName[team.Name] = team
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := d.sqlConn.Query("SELECT username,team FROM users WHERE team <> '' ORDER BY username;")
+	if err != nil {
+		logger.Error("failed-to-fetch-team-members", err)
+		return nil, err
+	}
+	defer members.Close()
+
+	for members.Next() {
+		var username, name string
+		err := members.Scan(&username, &name)
+		if err != nil {
+			logger.Error("failed-to-fetch-team-members", err)
+			return nil, err
+		}
+
+		if team, ok := byName[name]; ok {
+			team.Members = append(team.Members, username)
+		}
+	}
+
+	return teams, members.Err()
+}
+
+func scanTeam(row scanner) (*models.Team, error) {
+	var projectIDs string
+	var activityProcessedAt int64
+	team := &models.Team{Members: []string{}}
+
+	err := row.Scan(
+		&team.Name,
+		&projectIDs,
+		&team.Pool,
+		&team.Attribution,
+		&activityProcessedAt,
+		&team.Announcements.SlackWebhookURL,
+		&team.Announcements.MinTier,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if activityProcessedAt != 0 {
+		team.ActivityProcessedAt = time.Unix(0, activityProcessedAt)
+	}
+
+	team.TrackerProjectIDs, err = parseProjectIDs(projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (d *DB) UpdateTeam(logger lager.Logger, team models.Team) error {
+	projectIDs, err := marshalProjectIDs(team.TrackerProjectIDs)
+	if err != nil {
+		return err
+	}
+
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("updating-team", lager.Data{"team": team.Name})
+
+		// Switching attribution modes restarts the activity cursor so owners
+		// are not credited again for acceptances already awarded to the
+		// notification recipients.
+		result, err := tx.Exec(`
+		  UPDATE teams SET tracker_project_ids=$1,pool_id=$2,attribution=$3,
+		    activity_processed_at = CASE WHEN attribution = $3 THEN activity_processed_at ELSE 0 END
+		  WHERE name = $4;`,
+			projectIDs,
+			team.Pool,
+			team.Attribution,
+			team.Name,
+		)
+		if err != nil {
+			logger.Error("failed-updating-team", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
+
+// SetTeamActivityProcessedAt advances the cursor for the team's project
+// activity when its catches are credited to story owners.
+func (d *DB) SetTeamActivityProcessedAt(logger lager.Logger, name string, processedAt time.Time) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		return setTeamActivityProcessedAt(logger, tx, name, processedAt)
+	})
+}
+
+func setTeamActivityProcessedAt(logger lager.Logger, tx *sql.Tx, name string, processedAt time.Time) error {
+	result, err := tx.Exec(`
+	  UPDATE teams SET activity_processed_at = $1 WHERE name = $2;`,
+		processedAt.UnixNano(),
+		name,
+	)
+	if err != nil {
+		logger.Error("failed-updating-team", err)
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+func (d *DB) SetTeamAnnouncements(logger lager.Logger, name string, announcements models.Announcements) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("setting-team-announcements", lager.Data{"team": name, "min-tier": announcements.MinTier})
+		result, err := tx.Exec(`
+		  UPDATE teams SET slack_webhook_url = $1, slack_min_tier = $2 WHERE name = $3;`,
+			announcements.SlackWebhookURL,
+			announcements.MinTier,
+			name,
+		)
+		if err != nil {
+			logger.Error("failed-updating-team", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
+
+// DeleteTeam removes the team, its members' membership and any events
+// scoped to it.
+func (d *DB) DeleteTeam(logger lager.Logger, name string) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM teams WHERE name = $1;`, name)
+		if err != nil {
+			logger.Error("failed-deleting-team", err)
+			return err
+		}
+
+		err = requireRowsAffected(result)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE users SET team = '' WHERE team = $1;`, name)
+		if err != nil {
+			logger.Error("failed-removing-team-members", err)
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM events WHERE team = $1;`, name)
+		if err != nil {
+			logger.Error("failed-deleting-team-events", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// JoinTeam moves the user into the team, leaving any team they were in.
+func (d *DB) JoinTeam(logger lager.Logger, username, team string) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		var name string
+		err := tx.QueryRow(`SELECT name FROM teams WHERE name = $1;`, team).Scan(&name)
+		if err == sql.ErrNoRows {
+			return ResourceNotFound
+		}
+		if err != nil {
+			logger.Error("failed-to-fetch-team", err)
+			return err
+		}
+
+		result, err := tx.Exec(`UPDATE users SET team = $1 WHERE username = $2;`, team, username)
+		if err != nil {
+			logger.Error("failed-joining-team", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
+
+func (d *DB) LeaveTeam(logger lager.Logger, username, team string) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		result, err := tx.Exec(`UPDATE users SET team = '' WHERE username = $1 AND team = $2;`, username, team)
+		if err != nil {
+			logger.Error("failed-leaving-team", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
+
+func requireRowsAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ResourceNotFound
+	}
+
+	return nil
+}
+
+func marshalProjectIDs(ids []int) (string, error) {
+	if ids == nil {
+		ids = []int{}
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func parseProjectIDs(ids string) ([]int, error) {
+	result := []int{}
+	if ids == "" {
+		return result, nil
+	}
+
+	err := json.Unmarshal([]byte(ids), &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
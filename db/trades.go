@@ -0,0 +1,248 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+// ErrPokemonNotOwned is returned when a trade names a Pokemon one side does
+// not (or no longer) have.
+var ErrPokemonNotOwned = errors.New("pokemon-not-owned")
+
+const tradeColumns = "id,proposer,recipient,offered_pokemon,requested_pokemon,status,created_at,completed_at"
+
+// CreateTrade records a pending trade after checking that both sides own the
+// Pokemon involved, filling in their names from the owners' pokedexes.
+func (d *DB) CreateTrade(logger lager.Logger, trade models.Trade) (models.Trade, error) {
+	err := d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("inserting-trade", lager.Data{"proposer": trade.Proposer, "recipient": trade.Recipient})
+
+		proposerPokemon, recipientPokemon, err := lockTraders(logger, tx, trade.Proposer, trade.Recipient)
+		if err != nil {
+			return err
+		}
+
+		i := findPokemon(proposerPokemon, trade.Offered.Index)
+		j := findPokemon(recipientPokemon, trade.Requested.Index)
+		if i < 0 || j < 0 {
+			return ErrPokemonNotOwned
+		}
+		trade.Offered = proposerPokemon[i]
+		trade.Requested = recipientPokemon[j]
+		trade.Status = models.TradePending
+
+		offered, err := json.Marshal(trade.Offered)
+		if err != nil {
+			return err
+		}
+		requested, err := json.Marshal(trade.Requested)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`
+		  INSERT INTO trades(proposer,recipient,offered_pokemon,requested_pokemon,status,created_at) VALUES($1,$2,$3,$4,$5,$6) RETURNING id;`,
+			trade.Proposer,
+			trade.Recipient,
+			string(offered),
+			string(requested),
+			trade.Status,
+			trade.CreatedAt.UnixNano(),
+		).Scan(&trade.ID)
+		if err != nil {
+			logger.Error("failed-inserting-trade", err)
+			return err
+		}
+		return nil
+	})
+
+	return trade, err
+}
+
+func scanTrade(row scanner) (*models.Trade, error) {
+	var trade models.Trade
+	var offered, requested string
+	var createdAt, completedAt int64
+
+	err := row.Scan(
+		&trade.ID,
+		&trade.Proposer,
+		&trade.Recipient,
+		&offered,
+		&requested,
+		&trade.Status,
+		&createdAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(offered), &trade.Offered)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(requested), &trade.Requested)
+	if err != nil {
+		return nil, err
+	}
+
+	trade.CreatedAt = time.Unix(0, createdAt)
+	if completedAt != 0 {
+		trade.CompletedAt = time.Unix(0, completedAt)
+	}
+
+	return &trade, nil
+}
+
+// AcceptTrade completes a pending trade addressed to recipient, swapping the
+// two Pokemon between the pokedexes. It fails with ErrPokemonNotOwned if
+// either side has since lost theirs, leaving the trade pending.
+func (d *DB) AcceptTrade(logger lager.Logger, id int, recipient string, completedAt time.Time) (*models.Trade, error) {
+	var trade *models.Trade
+
+	err := d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("accepting-trade", lager.Data{"trade-id": id, "recipient": recipient})
+
+		var err error
+		trade, err = scanTrade(tx.QueryRow(`
+		  SELECT `+tradeColumns+` FROM trades WHERE id = $1 AND recipient = $2 AND status = $3 FOR UPDATE;`,
+			id,
+			recipient,
+			models.TradePending,
+		))
+		if err == sql.ErrNoRows {
+			return ResourceNotFound
+		}
+		if err != nil {
+			logger.Error("failed-to-fetch-trade", err)
+			return err
+		}
+
+		proposerPokemon, recipientPokemon, err := lockTraders(logger, tx, trade.Proposer, trade.Recipient)
+		if err != nil {
+			return err
+		}
+
+		i := findPokemon(proposerPokemon, trade.Offered.Index)
+		j := findPokemon(recipientPokemon, trade.Requested.Index)
+		if i < 0 || j < 0 {
+			return ErrPokemonNotOwned
+		}
+		offered, requested := proposerPokemon[i], recipientPokemon[j]
+
+		proposerPokemon = append(append(proposerPokemon[:i:i], proposerPokemon[i+1:]...), requested)
+		recipientPokemon = append(append(recipientPokemon[:j:j], recipientPokemon[j+1:]...), offered)
+
+		err = storePokemon(logger, tx, trade.Proposer, proposerPokemon)
+		if err != nil {
+			return err
+		}
+		err = storePokemon(logger, tx, trade.Recipient, recipientPokemon)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+		  UPDATE trades SET status = $1, completed_at = $2 WHERE id = $3;`,
+			models.TradeCompleted,
+			completedAt.UnixNano(),
+			id,
+		)
+		if err != nil {
+			logger.Error("failed-updating-trade", err)
+			return err
+		}
+
+		trade.Offered, trade.Requested = offered, requested
+		trade.Status = models.TradeCompleted
+		trade.CompletedAt = completedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trade, nil
+}
+
+// lockTraders reads both pokedexes, locking the users' rows until the
+// transaction ends. Rows are locked in username order so two trades between
+// the same pair cannot deadlock.
+func lockTraders(logger lager.Logger, tx *sql.Tx, proposer, recipient string) ([]models.Pokemon, []models.Pokemon, error) {
+	first, second := proposer, recipient
+	if second < first {
+		first, second = second, first
+	}
+
+	firstPokemon, err := lockPokemon(logger, tx, first)
+	if err != nil {
+		return nil, nil, err
+	}
+	secondPokemon, err := lockPokemon(logger, tx, second)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if first != proposer {
+		return secondPokemon, firstPokemon, nil
+	}
+	return firstPokemon, secondPokemon, nil
+}
+
+func lockPokemon(logger lager.Logger, tx *sql.Tx, username string) ([]models.Pokemon, error) {
+	var pokemonString string
+	err := tx.QueryRow(`
+	  SELECT pokemon FROM users WHERE username = $1 FOR UPDATE;`,
+		username,
+	).Scan(&pokemonString)
+	if err == sql.ErrNoRows {
+		return nil, ResourceNotFound
+	}
+	if err != nil {
+		logger.Error("failed-to-fetch-user", err)
+		return nil, err
+	}
+
+	pokemon, err := parsePokemonString(pokemonString)
+	if err != nil {
+		logger.Error("failed-to-parse-pokemon", err)
+		return nil, err
+	}
+
+	return pokemon, nil
+}
+
+func storePokemon(logger lager.Logger, tx *sql.Tx, username string, pokemon []models.Pokemon) error {
+	pokemonString, err := marshalPokemon(pokemon)
+	if err != nil {
+		logger.Error("failed-to-marshal-pokemon", err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+	  UPDATE users SET pokemon = $1 WHERE username = $2;`,
+		pokemonString,
+		username,
+	)
+	if err != nil {
+		logger.Error("failed-updating-user", err)
+		return err
+	}
+
+	return nil
+}
+
+func findPokemon(pokemon []models.Pokemon, index int) int {
+	for i, p := range pokemon {
+		if p.Index == index {
+			return i
+		}
+	}
+	return -1
+}
@@ -2,7 +2,7 @@ package db
 
 import (
 	"database/sql"
-	"strings"
+	"encoding/json"
 	"time"
 
 	"github.com/jfmyers9/gotta-track-em-all/models"
@@ -27,71 +27,114 @@ func (d *DB) CreateUser(logger lager.Logger, username string, trackerAPIToken st
 	})
 }
 
-func (d *DB) GetUser(logger lager.Logger, username string) (*models.User, error) {
-	row := d.sqlConn.QueryRow("SELECT pokemon,last_processed_at,tracker_api_token FROM users WHERE username = $1;", username)
+const userColumns = "username,pokemon,last_processed_at,tracker_api_token,streak_current,streak_longest,streak_last_active_day,team,tracker_project_ids,tracker_person_id,chat_user_id"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row scanner) (*models.User, error) {
+	var pokemonString string
+	var projectIDs string
+	var lastProcessedAt int64
+	user := &models.User{}
+
+	err := row.Scan(
+		&user.Username,
+		&pokemonString,
+		&lastProcessedAt,
+		&user.TrackerAPIToken,
+		&user.Streak.Current,
+		&user.Streak.Longest,
+		&user.Streak.LastActiveDay,
+		&user.Team,
+		&projectIDs,
+		&user.TrackerPersonID,
+		&user.ChatUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TrackerProjectIDs, err = parseProjectIDs(projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Pokemon, err = parsePokemonString(pokemonString)
+	if err != nil {
+		return nil, err
+	}
 
-	var pokemonString, trackerAPIToken string
-	var lastProcessedAt int
+	user.LastProcessedAt = time.Unix(0, lastProcessedAt)
+	return user, nil
+}
 
-	err := row.Scan(&pokemonString, &lastProcessedAt, &trackerAPIToken)
+func (d *DB) GetUser(logger lager.Logger, username string) (*models.User, error) {
+	row := d.sqlConn.QueryRow("SELECT "+userColumns+" FROM users WHERE username = $1;", username)
+
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, ResourceNotFound
+	}
 	if err != nil {
 		logger.Error("failed-to-fetch-user", err)
 		return nil, err
 	}
 
-	pokemon, err := parsePokemonString(pokemonString)
+	return user, nil
+}
+
+func (d *DB) GetUserByChatID(logger lager.Logger, chatUserID string) (*models.User, error) {
+	row := d.sqlConn.QueryRow("SELECT "+userColumns+" FROM users WHERE chat_user_id = $1 AND chat_user_id <> '';", chatUserID)
+
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, ResourceNotFound
+	}
 	if err != nil {
 		logger.Error("failed-to-fetch-user", err)
 		return nil, err
 	}
 
-	return &models.User{
-		Username:        username,
-		Pokemon:         pokemon,
-		LastProcessedAt: time.Unix(0, int64(lastProcessedAt)),
-		TrackerAPIToken: trackerAPIToken,
-	}, nil
+	return user, nil
 }
 
-func parsePokemonString(pokemonString string) ([]string, error) {
-	result := strings.Split(pokemonString, ",")
+func parsePokemonString(pokemonString string) ([]models.Pokemon, error) {
+	result := []models.Pokemon{}
+	if pokemonString == "" {
+		return result, nil
+	}
+
+	err := json.Unmarshal([]byte(pokemonString), &result)
+	if err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
 func (d *DB) Users(logger lager.Logger) ([]*models.User, error) {
-	rows, err := d.sqlConn.Query("SELECT username,pokemon,last_processed_at,tracker_api_token FROM users;")
+	rows, err := d.sqlConn.Query("SELECT " + userColumns + " FROM users;")
 	if err != nil {
 		logger.Error("failed-to-fetch-users", err)
 		return nil, err
 	}
+	defer rows.Close()
 
 	users := []*models.User{}
 
 	for rows.Next() {
-		var username, pokemonString, trackerAPIToken string
-		var lastProcessedAt int
-
-		err := rows.Scan(&username, &pokemonString, &lastProcessedAt, &trackerAPIToken)
+		user, err := scanUser(rows)
 		if err != nil {
 			logger.Error("failed-to-fetch-user", err)
 			return nil, err
 		}
 
-		pokemon, err := parsePokemonString(pokemonString)
-		if err != nil {
-			logger.Error("failed-to-fetch-user", err)
-			return nil, err
-		}
-
-		users = append(users, &models.User{
-			Username:        username,
-			Pokemon:         pokemon,
-			LastProcessedAt: time.Unix(0, int64(lastProcessedAt)),
-			TrackerAPIToken: trackerAPIToken,
-		})
+		users = append(users, user)
 	}
 
-	return users, nil
+	return users, rows.Err()
 }
 
 func (d *DB) UpdateUser(logger lager.Logger, username string, trackerAPIToken string) error {
@@ -111,28 +154,152 @@ func (d *DB) UpdateUser(logger lager.Logger, username string, trackerAPIToken st
 	})
 }
 
-func marshalPokemon(pokemon []string) string {
-	return strings.Join(pokemon, ",")
+// SetUserProjects limits the user's catches to acceptances in the given
+// Tracker projects. An empty list falls back to the team's projects.
+func (d *DB) SetUserProjects(logger lager.Logger, username string, projectIDs []int) error {
+	ids, err := marshalProjectIDs(projectIDs)
+	if err != nil {
+		logger.Error("failed-marshalling-project-ids", err)
+		return err
+	}
+
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("setting-user-projects", lager.Data{"username": username, "projects": projectIDs})
+
+		result, err := tx.Exec(`
+		  UPDATE users SET tracker_project_ids = $1 WHERE username = $2;`,
+			ids,
+			username,
+		)
+		if err != nil {
+			logger.Error("failed-updating-user", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
 }
 
-func (d *DB) AddUserPokemon(logger lager.Logger, username string, newPokemon []string, lastProcessedAt time.Time) error {
+func (d *DB) SetTrackerPersonID(logger lager.Logger, username string, personID int) error {
 	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
-		logger.Info("updating-user", lager.Data{"username": username})
+		result, err := tx.Exec(`
+		  UPDATE users SET tracker_person_id = $1 WHERE username = $2;`,
+			personID,
+			username,
+		)
+		if err != nil {
+			logger.Error("failed-updating-user", err)
+			return err
+		}
 
-		_, err := tx.Exec(`
-		  UPDATE users SET pokemon=$1,last_processed_at=$2 WHERE username = $3;`,
-			marshalPokemon(newPokemon),
-			lastProcessedAt.UnixNano(),
+		return requireRowsAffected(result)
+	})
+}
+
+// SetChatUserID links the user to their chat account. An empty ID unlinks it.
+func (d *DB) SetChatUserID(logger lager.Logger, username string, chatUserID string) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("setting-chat-user-id", lager.Data{"username": username, "chat-user-id": chatUserID})
+
+		result, err := tx.Exec(`
+		  UPDATE users SET chat_user_id = $1 WHERE username = $2;`,
+			chatUserID,
 			username,
 		)
 		if err != nil {
-			logger.Error("failed-inserting-user", err)
+			logger.Error("failed-updating-user", err)
 			return err
 		}
-		return nil
+
+		return requireRowsAffected(result)
 	})
 }
 
+func marshalPokemon(pokemon []models.Pokemon) (string, error) {
+	data, err := json.Marshal(pokemon)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// AddUserPokemon records the catches and their encounters, filling in the
+// encounters' IDs.
+func (d *DB) AddUserPokemon(logger lager.Logger, username string, caught []models.Pokemon, encounters []models.Encounter, streak models.Streak, lastProcessedAt time.Time) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		return addUserPokemon(logger, tx, username, caught, encounters, streak, lastProcessedAt)
+	})
+}
+
+// AwardOwners records every owner's catches from one pass over a team's
+// project activity together with the team's new activity cursor, so the
+// pass is recorded in full or not at all and a retry cannot pay anyone twice.
+func (d *DB) AwardOwners(logger lager.Logger, team string, awards []models.Award, activityProcessedAt, lastProcessedAt time.Time) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		for _, award := range awards {
+			err := addUserPokemon(logger, tx, award.Username, award.Caught, award.Encounters, award.Streak, lastProcessedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		return setTeamActivityProcessedAt(logger, tx, team, activityProcessedAt)
+	})
+}
+
+func addUserPokemon(logger lager.Logger, tx *sql.Tx, username string, caught []models.Pokemon, encounters []models.Encounter, streak models.Streak, lastProcessedAt time.Time) error {
+	logger.Info("updating-user", lager.Data{"username": username})
+
+	var pokemonString string
+	err := tx.QueryRow(`
+	  SELECT pokemon FROM users WHERE username = $1 FOR UPDATE;`,
+		username,
+	).Scan(&pokemonString)
+	if err == sql.ErrNoRows {
+		return ResourceNotFound
+	}
+	if err != nil {
+		logger.Error("failed-to-fetch-user", err)
+		return err
+	}
+
+	pokemon, err := parsePokemonString(pokemonString)
+	if err != nil {
+		logger.Error("failed-to-parse-pokemon", err)
+		return err
+	}
+
+	pokemonString, err = marshalPokemon(append(pokemon, caught...))
+	if err != nil {
+		logger.Error("failed-to-marshal-pokemon", err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+	  UPDATE users SET pokemon=$1,last_processed_at=$2,streak_current=$3,streak_longest=$4,streak_last_active_day=$5 WHERE username = $6;`,
+		pokemonString,
+		lastProcessedAt.UnixNano(),
+		streak.Current,
+		streak.Longest,
+		streak.LastActiveDay,
+		username,
+	)
+	if err != nil {
+		logger.Error("failed-inserting-user", err)
+		return err
+	}
+
+	for i := range encounters {
+		encounters[i].ID, err = insertEncounter(logger, tx, encounters[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return sequenceEncounters(logger, tx, encounters)
+}
+
 func (d *DB) DeleteUser(logger lager.Logger, username string) error {
 	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
 		_, err := tx.Exec(`
@@ -2,13 +2,17 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"strconv"
 
+	"github.com/jfmyers9/gotta-track-em-all/db/migrations"
 	"github.com/pivotal-golang/lager"
 )
 
 const VersionName = "version"
 
+var ErrMigrationsPending = errors.New("migrations-pending")
+
 func (d *DB) GetVersion(logger lager.Logger) (int, error) {
 	row := d.sqlConn.QueryRow(`SELECT value FROM configuration WHERE name = $1;`, VersionName)
 
@@ -55,3 +59,25 @@ func (d *DB) SetVersion(logger lager.Logger, version int) error {
 		return nil
 	})
 }
+
+func (d *DB) Ping() error {
+	return d.sqlConn.Ping()
+}
+
+// CheckVersion fails unless the schema is at the latest migration this
+// binary knows about.
+func (d *DB) CheckVersion(logger lager.Logger) error {
+	version, err := d.GetVersion(logger)
+	if err == ResourceNotFound {
+		return ErrMigrationsPending
+	}
+	if err != nil {
+		return err
+	}
+
+	if version != migrations.LatestVersion() {
+		return ErrMigrationsPending
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+func (d *DB) CreateWebhook(logger lager.Logger, webhook models.Webhook) (int, error) {
+	eventTypes, err := json.Marshal(webhook.EventTypes)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		logger.Info("inserting-webhook")
+		err := tx.QueryRow(`
+		  INSERT INTO webhooks(url,secret,event_types,created_at) VALUES($1,$2,$3,$4) RETURNING id;`,
+			webhook.URL,
+			webhook.Secret,
+			string(eventTypes),
+			webhook.CreatedAt.UnixNano(),
+		).Scan(&id)
+		if err != nil {
+			logger.Error("failed-inserting-webhook", err)
+			return err
+		}
+		return nil
+	})
+
+	return id, err
+}
+
+func (d *DB) Webhooks(logger lager.Logger) ([]models.Webhook, error) {
+	rows, err := d.sqlConn.Query(`SELECT id,url,secret,event_types,created_at FROM webhooks ORDER BY id;`)
+	if err != nil {
+		logger.Error("failed-to-fetch-webhooks", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+
+	for rows.Next() {
+		var webhook models.Webhook
+		var eventTypes string
+		var createdAt int64
+
+		err := rows.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &eventTypes, &createdAt)
+		if err != nil {
+			logger.Error("failed-to-fetch-webhook", err)
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(eventTypes), &webhook.EventTypes)
+		if err != nil {
+			logger.Error("failed-to-parse-event-types", err)
+			return nil, err
+		}
+
+		webhook.CreatedAt = time.Unix(0, createdAt)
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook removes the webhook along with its delivery log.
+func (d *DB) DeleteWebhook(logger lager.Logger, id int) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM webhooks WHERE id = $1;`, id)
+		if err != nil {
+			logger.Error("failed-deleting-webhook", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
+
+func (d *DB) EnqueueWebhookDeliveries(logger lager.Logger, deliveries []models.WebhookDelivery) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		for _, delivery := range deliveries {
+			_, err := tx.Exec(`
+			  INSERT INTO webhook_deliveries(webhook_id,event_type,payload,status,next_attempt_at,created_at) VALUES($1,$2,$3,$4,$5,$6);`,
+				delivery.WebhookID,
+				delivery.EventType,
+				delivery.Payload,
+				models.DeliveryPending,
+				delivery.NextAttemptAt.UnixNano(),
+				delivery.CreatedAt.UnixNano(),
+			)
+			if err != nil {
+				logger.Error("failed-inserting-webhook-delivery", err)
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+const deliveryColumns = "id,webhook_id,event_type,payload,status,attempts,last_status_code,last_error,next_attempt_at,created_at,delivered_at"
+
+// DueWebhookDeliveries returns up to limit pending deliveries whose next
+// attempt is due, oldest first.
+func (d *DB) DueWebhookDeliveries(logger lager.Logger, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := d.sqlConn.Query(`
+	  SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE status = $1 AND next_attempt_at <= $2 ORDER BY id LIMIT $3;`,
+		models.DeliveryPending,
+		now.UnixNano(),
+		limit,
+	)
+	if err != nil {
+		logger.Error("failed-to-fetch-webhook-deliveries", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(logger, rows)
+}
+
+// WebhookDeliveries returns the webhook's most recent deliveries, newest
+// first.
+func (d *DB) WebhookDeliveries(logger lager.Logger, webhookID int, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := d.sqlConn.Query(`
+	  SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2;`,
+		webhookID,
+		limit,
+	)
+	if err != nil {
+		logger.Error("failed-to-fetch-webhook-deliveries", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(logger, rows)
+}
+
+func scanDeliveries(logger lager.Logger, rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var nextAttemptAt, createdAt, deliveredAt int64
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&nextAttemptAt,
+			&createdAt,
+			&deliveredAt,
+		)
+		if err != nil {
+			logger.Error("failed-to-fetch-webhook-delivery", err)
+			return nil, err
+		}
+
+		delivery.NextAttemptAt = time.Unix(0, nextAttemptAt)
+		delivery.CreatedAt = time.Unix(0, createdAt)
+		if deliveredAt != 0 {
+			delivery.DeliveredAt = time.Unix(0, deliveredAt)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt.
+func (d *DB) UpdateWebhookDelivery(logger lager.Logger, delivery models.WebhookDelivery) error {
+	var deliveredAt int64
+	if !delivery.DeliveredAt.IsZero() {
+		deliveredAt = delivery.DeliveredAt.UnixNano()
+	}
+
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		result, err := tx.Exec(`
+		  UPDATE webhook_deliveries SET status=$1,attempts=$2,last_status_code=$3,last_error=$4,next_attempt_at=$5,delivered_at=$6 WHERE id = $7;`,
+			delivery.Status,
+			delivery.Attempts,
+			delivery.LastStatusCode,
+			delivery.LastError,
+			delivery.NextAttemptAt.UnixNano(),
+			deliveredAt,
+			delivery.ID,
+		)
+		if err != nil {
+			logger.Error("failed-updating-webhook-delivery", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
+
+// RetryWebhookDelivery moves a delivery, typically a dead one, back to
+// pending with a fresh set of attempts.
+func (d *DB) RetryWebhookDelivery(logger lager.Logger, webhookID, deliveryID int, now time.Time) error {
+	return d.transact(logger, func(logger lager.Logger, tx *sql.Tx) error {
+		result, err := tx.Exec(`
+		  UPDATE webhook_deliveries SET status=$1,attempts=0,next_attempt_at=$2 WHERE id = $3 AND webhook_id = $4;`,
+			models.DeliveryPending,
+			now.UnixNano(),
+			deliveryID,
+			webhookID,
+		)
+		if err != nil {
+			logger.Error("failed-updating-webhook-delivery", err)
+			return err
+		}
+
+		return requireRowsAffected(result)
+	})
+}
@@ -0,0 +1,50 @@
+package encounter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+var (
+	ErrInvalidMultiplier = errors.New("boost-multiplier-must-be-positive")
+	ErrEmptyBoost        = errors.New("boost-must-target-species-or-tiers")
+)
+
+// Boost multiplies the weight of every listed species and every species in
+// the listed rarity tiers. The catalog carries no Pokemon types, so boosting
+// by type is not supported; list the species instead.
+type Boost struct {
+	Species    []int
+	Tiers      []string
+	Multiplier float64
+}
+
+func (b Boost) Validate() error {
+	if math.IsNaN(b.Multiplier) || math.IsInf(b.Multiplier, 0) || b.Multiplier <= 0 {
+		return ErrInvalidMultiplier
+	}
+
+	if len(b.Species) == 0 && len(b.Tiers) == 0 {
+		return ErrEmptyBoost
+	}
+
+	return nil
+}
+
+func (b Boost) applies(entry models.PokemonEntry) bool {
+	for _, index := range b.Species {
+		if index == entry.Index {
+			return true
+		}
+	}
+
+	for _, tier := range b.Tiers {
+		if tier == entry.Tier {
+			return true
+		}
+	}
+
+	return false
+}
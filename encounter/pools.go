@@ -0,0 +1,204 @@
+package encounter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+const DefaultPool = "all"
+
+// A species belongs to a pool when it is from one of the listed generations
+// or regions (any, if none are listed), is not in an excluded tier and is
+// not explicitly excluded. Include adds species regardless of the other
+// filters. Tiers come from base experience, so excluding the legendary tier
+// drops the rarest species rather than the canonical legendaries; list
+// those in Exclude to leave them out.
+type PoolConfig struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Generations  []int    `json:"generations"`
+	Regions      []string `json:"regions"`
+	ExcludeTiers []string `json:"exclude_tiers"`
+	Include      []int    `json:"include"`
+	Exclude      []int    `json:"exclude"`
+}
+
+var DefaultPools = []PoolConfig{
+	{ID: DefaultPool, Name: "Every species"},
+	{ID: "gen1", Name: "Generation 1 only", Regions: []string{"kanto"}},
+	{ID: "no-legendaries", Name: "Exclude legendary and mythical species", Exclude: Legendaries},
+	{ID: "no-legendary-tier", Name: "Exclude the legendary rarity tier", ExcludeTiers: []string{Legendary}},
+}
+
+// Legendaries are the national dex numbers of the legendary and mythical
+// species in the catalog, generations 1 to 6.
+var Legendaries = []int{
+	144, 145, 146, 150, 151,
+	243, 244, 245, 249, 250, 251,
+	377, 378, 379, 380, 381, 382, 383, 384, 385, 386,
+	480, 481, 482, 483, 484, 485, 486, 487, 488, 489, 490, 491, 492, 493,
+	494, 638, 639, 640, 641, 642, 643, 644, 645, 646, 647, 648, 649,
+	716, 717, 718, 719, 720, 721,
+}
+
+var Regions = map[string]int{
+	"kanto":  1,
+	"johto":  2,
+	"hoenn":  3,
+	"sinnoh": 4,
+	"unova":  5,
+	"kalos":  6,
+}
+
+var lastIndexInGeneration = []int{151, 251, 386, 493, 649, 721}
+
+// Generation returns the generation a national dex number was introduced in,
+// or 0 if it is outside the known generations.
+func Generation(index int) int {
+	for i, last := range lastIndexInGeneration {
+		if index <= last {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+type InvalidPoolError struct {
+	ID     string
+	Reason string
+}
+
+func (e InvalidPoolError) Error() string {
+	return fmt.Sprintf("invalid-pool: %s: %s", e.ID, e.Reason)
+}
+
+func LoadPools(path string) ([]PoolConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pools := []PoolConfig{}
+	err = json.NewDecoder(file).Decode(&pools)
+	if err != nil {
+		return nil, err
+	}
+
+	return pools, nil
+}
+
+func (p PoolConfig) generations() (map[int]bool, error) {
+	generations := map[int]bool{}
+
+	for _, generation := range p.Generations {
+		if generation < 1 || generation > len(lastIndexInGeneration) {
+			return nil, InvalidPoolError{p.ID, fmt.Sprintf("unknown generation %d", generation)}
+		}
+		generations[generation] = true
+	}
+
+	for _, region := range p.Regions {
+		generation, ok := Regions[region]
+		if !ok {
+			return nil, InvalidPoolError{p.ID, fmt.Sprintf("unknown region %q", region)}
+		}
+		generations[generation] = true
+	}
+
+	return generations, nil
+}
+
+func (p PoolConfig) filter(entries []models.PokemonEntry, tiers []TierConfig) ([]models.PokemonEntry, error) {
+	generations, err := p.generations()
+	if err != nil {
+		return nil, err
+	}
+
+	excludedTiers := map[string]bool{}
+	for _, tier := range p.ExcludeTiers {
+		excludedTiers[tier] = true
+	}
+
+	included := map[int]bool{}
+	for _, index := range p.Include {
+		included[index] = true
+	}
+
+	excluded := map[int]bool{}
+	for _, index := range p.Exclude {
+		excluded[index] = true
+	}
+
+	filtered := []models.PokemonEntry{}
+	for _, entry := range entries {
+		matches := len(generations) == 0 || generations[Generation(entry.Index)]
+		matches = matches && !excludedTiers[tierFor(tiers, entry.Weight)] && !excluded[entry.Index]
+
+		if matches || included[entry.Index] {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Pools holds an encounter table for every configured pool.
+type Pools struct {
+	tables  map[string]*Table
+	configs []PoolConfig
+	tiers   []TierConfig
+}
+
+// NewPools builds the default pools followed by configs, with later pools
+// replacing earlier ones that share an id.
+func NewPools(entries []models.PokemonEntry, tiers []TierConfig, configs []PoolConfig) (*Pools, error) {
+	pools := &Pools{tables: map[string]*Table{}, tiers: tiers}
+
+	for _, config := range append(append([]PoolConfig{}, DefaultPools...), configs...) {
+		if config.ID == "" {
+			return nil, InvalidPoolError{config.ID, "id is required"}
+		}
+
+		filtered, err := config.filter(entries, tiers)
+		if err != nil {
+			return nil, err
+		}
+
+		table, err := NewTable(filtered, tiers)
+		if err != nil {
+			return nil, InvalidPoolError{config.ID, err.Error()}
+		}
+		table.pool = config.ID
+
+		if _, ok := pools.tables[config.ID]; ok {
+			for i := range pools.configs {
+				if pools.configs[i].ID == config.ID {
+					pools.configs[i] = config
+				}
+			}
+		} else {
+			pools.configs = append(pools.configs, config)
+		}
+		pools.tables[config.ID] = table
+	}
+
+	return pools, nil
+}
+
+func (p *Pools) Get(id string) (*Table, bool) {
+	table, ok := p.tables[id]
+	return table, ok
+}
+
+func (p *Pools) Configs() []PoolConfig {
+	return p.configs
+}
+
+func (p *Pools) Tiers() []TierConfig {
+	return p.tiers
+}
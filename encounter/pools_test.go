@@ -0,0 +1,43 @@
+package encounter
+
+import (
+	"testing"
+
+	"github.com/jfmyers9/gotta-track-em-all/catalog"
+)
+
+func TestNoLegendariesPoolExcludesLegendarySpecies(t *testing.T) {
+	entries, err := catalog.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pools, err := NewPools(entries, DefaultTiers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, ok := pools.Get("no-legendaries")
+	if !ok {
+		t.Fatal("no-legendaries pool is not configured")
+	}
+
+	legendary := map[int]bool{}
+	for _, index := range Legendaries {
+		legendary[index] = true
+	}
+
+	rollable := map[int]bool{}
+	for _, entry := range table.Entries() {
+		if legendary[entry.Index] {
+			t.Errorf("#%d %s is legendary but can be rolled", entry.Index, entry.Name)
+		}
+		rollable[entry.Index] = true
+	}
+
+	for _, entry := range entries {
+		if !legendary[entry.Index] && !rollable[entry.Index] {
+			t.Errorf("#%d %s is not legendary but cannot be rolled", entry.Index, entry.Name)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package encounter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+var (
+	ErrEmptyCatalog = errors.New("empty-catalog")
+	ErrInvalidRoll  = errors.New("roll-out-of-range")
+	ErrNoTiers      = errors.New("no-rollable-tiers")
+)
+
+type InvalidEntryError struct {
+	Index  int
+	Reason string
+}
+
+func (e InvalidEntryError) Error() string {
+	return fmt.Sprintf("invalid-catalog-entry: pokemon %d: %s", e.Index, e.Reason)
+}
+
+type bucket struct {
+	tier       string
+	entries    []models.PokemonEntry
+	cumulative []float64
+	baseWeight float64
+}
+
+// Table selects a rarity tier by its configured probability and then a
+// species within that tier in proportion to its weight. Both stages are
+// stored as running totals so a single roll resolves with two binary
+// searches.
+type Table struct {
+	pool       string
+	tiers      []TierConfig
+	entries    []models.PokemonEntry
+	buckets    []*bucket
+	cumulative []float64
+	version    string
+}
+
+func NewTable(entries []models.PokemonEntry, tiers []TierConfig) (*Table, error) {
+	if len(entries) == 0 {
+		return nil, ErrEmptyCatalog
+	}
+
+	err := ValidateTiers(tiers)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	tiered := make([]models.PokemonEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		if seen[entry.Index] {
+			return nil, InvalidEntryError{entry.Index, "duplicate index"}
+		}
+		seen[entry.Index] = true
+
+		if math.IsNaN(entry.Weight) || math.IsInf(entry.Weight, 0) || entry.Weight <= 0 {
+			return nil, InvalidEntryError{entry.Index, "weight must be positive"}
+		}
+
+		entry.Tier = tierFor(tiers, entry.Weight)
+		tiered = append(tiered, entry)
+	}
+
+	return build(DefaultPool, tiered, tiers, nil)
+}
+
+// WithBoosts returns a copy of the table with the boosted species' weights
+// multiplied. Species keep the tier of their unboosted weight; instead each
+// tier's probability grows with its share of boosted weight, so a boost
+// raises a species' overall odds rather than only its odds within its tier.
+func (t *Table) WithBoosts(boosts []Boost) (*Table, error) {
+	if len(boosts) == 0 {
+		return t, nil
+	}
+
+	for _, boost := range boosts {
+		err := boost.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return build(t.pool, t.entries, t.tiers, boosts)
+}
+
+func build(pool string, entries []models.PokemonEntry, tiers []TierConfig, boosts []Boost) (*Table, error) {
+	byTier := map[string]*bucket{}
+
+	for _, entry := range entries {
+		b, ok := byTier[entry.Tier]
+		if !ok {
+			b = &bucket{tier: entry.Tier}
+			byTier[entry.Tier] = b
+		}
+
+		weight := entry.Weight
+		for _, boost := range boosts {
+			if boost.applies(entry) {
+				weight *= boost.Multiplier
+			}
+		}
+
+		total := weight
+		if len(b.cumulative) > 0 {
+			total += b.cumulative[len(b.cumulative)-1]
+		}
+		b.entries = append(b.entries, entry)
+		b.cumulative = append(b.cumulative, total)
+		b.baseWeight += entry.Weight
+	}
+
+	table := &Table{
+		pool:    pool,
+		tiers:   tiers,
+		entries: entries,
+		version: version(entries, tiers, boosts),
+	}
+
+	total := 0.0
+	for _, tier := range tiers {
+		b, ok := byTier[tier.Name]
+		if !ok || tier.Probability == 0 {
+			continue
+		}
+
+		boostedWeight := b.cumulative[len(b.cumulative)-1]
+		total += tier.Probability * boostedWeight / b.baseWeight
+		table.buckets = append(table.buckets, b)
+		table.cumulative = append(table.cumulative, total)
+	}
+
+	if len(table.buckets) == 0 {
+		return nil, ErrNoTiers
+	}
+
+	return table, nil
+}
+
+// Select maps a roll in [0, 1) onto a species. The position of the roll
+// within the chosen tier's band is reused to pick the species, so the same
+// roll always yields the same result for a given table.
+func (t *Table) Select(roll float64) (models.PokemonEntry, error) {
+	if roll < 0 || roll >= 1 || math.IsNaN(roll) {
+		return models.PokemonEntry{}, ErrInvalidRoll
+	}
+
+	target := roll * t.cumulative[len(t.cumulative)-1]
+	i := search(t.cumulative, target)
+	if i == len(t.cumulative) {
+		return models.PokemonEntry{}, ErrInvalidRoll
+	}
+
+	low := 0.0
+	if i > 0 {
+		low = t.cumulative[i-1]
+	}
+	within := (target - low) / (t.cumulative[i] - low)
+
+	b := t.buckets[i]
+	j := search(b.cumulative, within*b.cumulative[len(b.cumulative)-1])
+	if j == len(b.cumulative) {
+		j = len(b.cumulative) - 1
+	}
+
+	return b.entries[j], nil
+}
+
+func search(cumulative []float64, target float64) int {
+	return sort.Search(len(cumulative), func(i int) bool {
+		return target < cumulative[i]
+	})
+}
+
+func (t *Table) Pool() string {
+	return t.pool
+}
+
+func (t *Table) Entries() []models.PokemonEntry {
+	return t.entries
+}
+
+// Version fingerprints the table so that a logged roll can be replayed
+// against the exact catalog, tiers and boosts it was drawn from.
+func (t *Table) Version() string {
+	return t.version
+}
+
+func version(entries []models.PokemonEntry, tiers []TierConfig, boosts []Boost) string {
+	hash := sha256.New()
+	for _, tier := range tiers {
+		fmt.Fprintf(hash, "%s,%s,%s\n", tier.Name, formatFloat(tier.MinWeight), formatFloat(tier.Probability))
+	}
+	for _, boost := range boosts {
+		fmt.Fprintf(hash, "boost,%v,%v,%s\n", boost.Species, boost.Tiers, formatFloat(boost.Multiplier))
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(hash, "%d,%s,%s\n", entry.Index, entry.Name, formatFloat(entry.Weight))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
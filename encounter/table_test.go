@@ -0,0 +1,229 @@
+package encounter
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+var singleTier = []TierConfig{{Name: "all", MinWeight: 0, Probability: 1}}
+
+// weights is a random catalog of 1 to 20 species with positive weights
+// spanning a few orders of magnitude, so they land in different tiers.
+type weights []float64
+
+func (weights) Generate(r *rand.Rand, size int) reflect.Value {
+	w := make(weights, 1+r.Intn(20))
+	for i := range w {
+		w[i] = math.Pow(10, -3*r.Float64())
+	}
+	return reflect.ValueOf(w)
+}
+
+func (w weights) entries() []models.PokemonEntry {
+	entries := []models.PokemonEntry{}
+	for i, weight := range w {
+		entries = append(entries, models.PokemonEntry{Index: i + 1, Weight: weight})
+	}
+	return entries
+}
+
+// expected is the chance of rolling each species: its tier's share of the
+// probability of the tiers that hold any species, split within the tier in
+// proportion to weight.
+func expected(entries []models.PokemonEntry, tiers []TierConfig) map[int]float64 {
+	tierWeight := map[string]float64{}
+	for _, entry := range entries {
+		tierWeight[tierFor(tiers, entry.Weight)] += entry.Weight
+	}
+
+	total := 0.0
+	for _, tier := range tiers {
+		if tierWeight[tier.Name] > 0 {
+			total += tier.Probability
+		}
+	}
+
+	probabilities := map[int]float64{}
+	for _, entry := range entries {
+		tier := tierFor(tiers, entry.Weight)
+		for _, t := range tiers {
+			if t.Name == tier {
+				probabilities[entry.Index] = t.Probability / total * entry.Weight / tierWeight[tier]
+			}
+		}
+	}
+	return probabilities
+}
+
+func checkDistribution(t *testing.T, entries []models.PokemonEntry, tiers []TierConfig, seed int64) bool {
+	const samples = 100000
+
+	table, err := NewTable(entries, tiers)
+	if err != nil {
+		t.Logf("NewTable: %s", err)
+		return false
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	counts := map[int]int{}
+	for i := 0; i < samples; i++ {
+		entry, err := table.Select(random.Float64())
+		if err != nil {
+			t.Logf("Select: %s", err)
+			return false
+		}
+		counts[entry.Index]++
+	}
+
+	for index, p := range expected(entries, tiers) {
+		observed := float64(counts[index]) / samples
+		// Five standard deviations of the binomial, plus slack for rounding.
+		tolerance := 5*math.Sqrt(p*(1-p)/samples) + 1e-4
+		if math.Abs(observed-p) > tolerance {
+			t.Logf("pokemon %d: observed %.5f, expected %.5f (tolerance %.5f)", index, observed, p, tolerance)
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSelectMatchesWeights(t *testing.T) {
+	property := func(w weights, seed int64) bool {
+		return checkDistribution(t, w.entries(), singleTier, seed)
+	}
+
+	err := quick.Check(property, &quick.Config{MaxCount: 20, Rand: rand.New(rand.NewSource(1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectMatchesTierProbabilities(t *testing.T) {
+	tiers := []TierConfig{
+		{Name: Common, MinWeight: 0.1, Probability: 0.6},
+		{Name: Rare, MinWeight: 0.01, Probability: 0.3},
+		{Name: Legendary, MinWeight: 0, Probability: 0.1},
+	}
+
+	property := func(w weights, seed int64) bool {
+		return checkDistribution(t, w.entries(), tiers, seed)
+	}
+
+	err := quick.Check(property, &quick.Config{MaxCount: 20, Rand: rand.New(rand.NewSource(2))})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewTableValidation(t *testing.T) {
+	_, err := NewTable(nil, DefaultTiers)
+	if err != ErrEmptyCatalog {
+		t.Errorf("empty catalog: got %v, want %v", err, ErrEmptyCatalog)
+	}
+
+	cases := []struct {
+		name    string
+		entries []models.PokemonEntry
+		want    InvalidEntryError
+	}{
+		{
+			name:    "zero weight",
+			entries: []models.PokemonEntry{{Index: 1, Weight: 1}, {Index: 2, Weight: 0}},
+			want:    InvalidEntryError{2, "weight must be positive"},
+		},
+		{
+			name:    "negative weight",
+			entries: []models.PokemonEntry{{Index: 1, Weight: -0.5}},
+			want:    InvalidEntryError{1, "weight must be positive"},
+		},
+		{
+			name:    "NaN weight",
+			entries: []models.PokemonEntry{{Index: 3, Weight: math.NaN()}},
+			want:    InvalidEntryError{3, "weight must be positive"},
+		},
+		{
+			name:    "duplicate index",
+			entries: []models.PokemonEntry{{Index: 7, Weight: 1}, {Index: 7, Weight: 2}},
+			want:    InvalidEntryError{7, "duplicate index"},
+		},
+	}
+
+	for _, c := range cases {
+		_, err := NewTable(c.entries, DefaultTiers)
+		if err != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+func TestSearchEdges(t *testing.T) {
+	cumulative := []float64{1, 2, 4}
+
+	cases := []struct {
+		target float64
+		want   int
+	}{
+		{0, 0},
+		{0.5, 0},
+		{1, 1}, // a target on a boundary belongs to the next entry
+		{2, 2},
+		{math.Nextafter(4, 0), 2},
+		{4, 3},
+	}
+
+	for _, c := range cases {
+		got := search(cumulative, c.target)
+		if got != c.want {
+			t.Errorf("search(%v): got %d, want %d", c.target, got, c.want)
+		}
+	}
+}
+
+func TestSelectEdges(t *testing.T) {
+	entries := []models.PokemonEntry{
+		{Index: 1, Weight: 1},
+		{Index: 2, Weight: 1},
+		{Index: 3, Weight: 2},
+	}
+
+	table, err := NewTable(entries, singleTier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		roll float64
+		want int
+	}{
+		{"first entry", 0, 1},
+		{"just below a boundary", math.Nextafter(0.25, 0), 1},
+		{"exactly on a boundary", 0.25, 2},
+		{"exactly on the last boundary", 0.5, 3},
+		{"last entry", math.Nextafter(1, 0), 3},
+	}
+
+	for _, c := range cases {
+		entry, err := table.Select(c.roll)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if entry.Index != c.want {
+			t.Errorf("%s: roll %v selected %d, want %d", c.name, c.roll, entry.Index, c.want)
+		}
+	}
+
+	for _, roll := range []float64{-0.1, 1, math.NaN()} {
+		_, err := table.Select(roll)
+		if err != ErrInvalidRoll {
+			t.Errorf("roll %v: got %v, want %v", roll, err, ErrInvalidRoll)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package encounter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+const (
+	Common    = "common"
+	Uncommon  = "uncommon"
+	Rare      = "rare"
+	Legendary = "legendary"
+)
+
+// A species falls into the first tier whose MinWeight its weight meets, so
+// tiers are listed from most to least common. Probability is the chance of
+// rolling the tier at all, independent of how many species it holds.
+type TierConfig struct {
+	Name        string  `json:"name"`
+	MinWeight   float64 `json:"min_weight"`
+	Probability float64 `json:"probability"`
+}
+
+// Thresholds correspond to base experience of 100, 175 and 250 under the
+// inverse-experience weighting used by the catalog.
+var DefaultTiers = []TierConfig{
+	{Name: Common, MinWeight: 1.0 / 100, Probability: 0.60},
+	{Name: Uncommon, MinWeight: 1.0 / 175, Probability: 0.28},
+	{Name: Rare, MinWeight: 1.0 / 250, Probability: 0.10},
+	{Name: Legendary, MinWeight: 0, Probability: 0.02},
+}
+
+type InvalidTierError struct {
+	Name   string
+	Reason string
+}
+
+func (e InvalidTierError) Error() string {
+	return fmt.Sprintf("invalid-tier: %s: %s", e.Name, e.Reason)
+}
+
+func LoadTiers(path string) ([]TierConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tiers := []TierConfig{}
+	err = json.NewDecoder(file).Decode(&tiers)
+	if err != nil {
+		return nil, err
+	}
+
+	return tiers, ValidateTiers(tiers)
+}
+
+func ValidateTiers(tiers []TierConfig) error {
+	if len(tiers) == 0 {
+		return InvalidTierError{"", "at least one tier is required"}
+	}
+
+	seen := map[string]bool{}
+	total := 0.0
+
+	for i, tier := range tiers {
+		if tier.Name == "" {
+			return InvalidTierError{tier.Name, "name is required"}
+		}
+		if seen[tier.Name] {
+			return InvalidTierError{tier.Name, "duplicate name"}
+		}
+		seen[tier.Name] = true
+
+		if math.IsNaN(tier.Probability) || math.IsInf(tier.Probability, 0) || tier.Probability < 0 {
+			return InvalidTierError{tier.Name, "probability must not be negative"}
+		}
+		total += tier.Probability
+
+		if i > 0 && tier.MinWeight >= tiers[i-1].MinWeight {
+			return InvalidTierError{tier.Name, "min_weight must decrease from tier to tier"}
+		}
+	}
+
+	if tiers[len(tiers)-1].MinWeight > 0 {
+		return InvalidTierError{tiers[len(tiers)-1].Name, "last tier must have a min_weight of 0"}
+	}
+
+	if total <= 0 {
+		return InvalidTierError{"", "tier probabilities must not all be zero"}
+	}
+
+	return nil
+}
+
+// AtLeast reports whether tier is as rare as min or rarer. An empty min
+// matches every known tier; unknown tiers never match.
+func AtLeast(tiers []TierConfig, tier, min string) bool {
+	rank, minRank := -1, -1
+	if min == "" {
+		minRank = 0
+	}
+	for i, t := range tiers {
+		if t.Name == tier {
+			rank = i
+		}
+		if t.Name == min {
+			minRank = i
+		}
+	}
+
+	return rank >= 0 && minRank >= 0 && rank >= minRank
+}
+
+func tierFor(tiers []TierConfig, weight float64) string {
+	for _, tier := range tiers {
+		if weight >= tier.MinWeight {
+			return tier.Name
+		}
+	}
+
+	return tiers[len(tiers)-1].Name
+}
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"sync"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const subscriptionBuffer = 64
+
+// Filter limits a subscription to one user's or one team's events. The zero
+// Filter matches everything.
+type Filter struct {
+	Username string
+	Team     string
+}
+
+func (f Filter) Matches(event models.GameEvent) bool {
+	if f.Username != "" && event.Username != f.Username {
+		return false
+	}
+	if f.Team != "" && event.Team != f.Team {
+		return false
+	}
+	return true
+}
+
+// Subscription receives matching events on Events. Events is closed when the
+// subscriber falls too far behind; it should reconnect and resume from the
+// last event ID it saw.
+type Subscription struct {
+	Events <-chan models.GameEvent
+
+	events chan models.GameEvent
+	filter Filter
+}
+
+// Broker fans published events out to in-process subscribers such as the
+// live feed. Publishing never blocks the watcher.
+type Broker struct {
+	lock          sync.Mutex
+	subscriptions map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscriptions: map[*Subscription]struct{}{},
+	}
+}
+
+func (b *Broker) Subscribe(filter Filter) *Subscription {
+	events := make(chan models.GameEvent, subscriptionBuffer)
+	subscription := &Subscription{Events: events, events: events, filter: filter}
+
+	b.lock.Lock()
+	b.subscriptions[subscription] = struct{}{}
+	b.lock.Unlock()
+
+	return subscription
+}
+
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.subscriptions[subscription]; ok {
+		delete(b.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+func (b *Broker) Publish(logger lager.Logger, event models.GameEvent) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for subscription := range b.subscriptions {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			logger.Info("dropping-slow-subscriber", lager.Data{"username": subscription.filter.Username, "team": subscription.filter.Team})
+			delete(b.subscriptions, subscription)
+			close(subscription.events)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// requireAdmin rejects requests that do not carry the admin token as a bearer
+// token. When no admin token is configured every request is forbidden.
+func requireAdmin(logger lager.Logger, adminToken string, handler http.Handler) http.Handler {
+	if adminToken == "" {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			logger.Info("admin-token-not-configured", lager.Data{"path": req.URL.Path})
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			logger.Info("unauthorized-admin-request", lager.Data{"path": req.URL.Path})
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	ChatTimestampHeader = "X-Slack-Request-Timestamp"
+	ChatSignatureHeader = "X-Slack-Signature"
+
+	// Requests older than this are rejected so a captured request cannot be
+	// replayed.
+	chatMaxSkew = 5 * time.Minute
+
+	chatLeaderboardSize = 10
+	chatRecentCatches   = 5
+)
+
+const chatUsage = "Usage: `/pokedex me`, `/pokedex leaderboard`, `/pokedex trade @user <your #> for <their #>`, `/pokedex accept <trade id>`"
+
+// Publisher is told about trades once they complete.
+type Publisher interface {
+	Publish(logger lager.Logger, event models.GameEvent)
+}
+
+type ChatResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+type ChatHandler struct {
+	logger        lager.Logger
+	d             *db.DB
+	calendar      streak.Calendar
+	publisher     Publisher
+	signingSecret string
+}
+
+func NewChatHandler(logger lager.Logger, d *db.DB, calendar streak.Calendar, publisher Publisher, signingSecret string) ChatHandler {
+	return ChatHandler{logger, d, calendar, publisher, signingSecret}
+}
+
+// SignChatRequest returns the signature Slack sends for body at timestamp.
+func SignChatRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c ChatHandler) verify(req *http.Request, body []byte, now time.Time) bool {
+	timestamp := req.Header.Get(ChatTimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > chatMaxSkew || skew < -chatMaxSkew {
+		return false
+	}
+
+	expected := SignChatRequest(c.signingSecret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(req.Header.Get(ChatSignatureHeader)))
+}
+
+// Command answers Slack-style slash commands. The chat user must have been
+// linked to a registered user with SetChatUserID.
+func (c ChatHandler) Command(w http.ResponseWriter, req *http.Request) {
+	logger := c.logger.Session("chat-command")
+
+	if c.signingSecret == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if !c.verify(req, body, now) {
+		logger.Info("invalid-signature")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chatUserID := form.Get("user_id")
+	logger = logger.WithData(lager.Data{"chat-user-id": chatUserID})
+
+	user, err := c.d.GetUserByChatID(logger, chatUserID)
+	if err == db.ResourceNotFound {
+		writeJSON(logger, w, ephemeral("Your chat account is not linked to a pokedex user. Ask an admin to run `pokedex link-chat`."))
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-user", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	args := strings.Fields(form.Get("text"))
+	if len(args) == 0 {
+		writeJSON(logger, w, ephemeral(chatUsage))
+		return
+	}
+
+	var response ChatResponse
+	switch args[0] {
+	case "me":
+		response, err = c.me(logger, user, now)
+	case "leaderboard":
+		response, err = c.leaderboard(logger, user, now)
+	case "trade":
+		response, err = c.trade(logger, user, args[1:], now)
+	case "accept":
+		response, err = c.accept(logger, user, args[1:], now)
+	default:
+		response = ephemeral(chatUsage)
+	}
+	if err != nil {
+		logger.Error("failed-to-run-command", err, lager.Data{"command": args[0]})
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(logger, w, response)
+}
+
+func (c ChatHandler) me(logger lager.Logger, user *models.User, now time.Time) (ChatResponse, error) {
+	unique := map[int]bool{}
+	for _, pokemon := range user.Pokemon {
+		unique[pokemon.Index] = true
+	}
+
+	current := streak.Current(c.calendar, user.Streak, now)
+
+	text := fmt.Sprintf("*%s*: %d caught, %d unique, %d day streak (longest %d)",
+		user.Username, len(user.Pokemon), len(unique), current.Current, current.Longest)
+
+	recent := []string{}
+	for i := len(user.Pokemon) - 1; i >= 0 && len(recent) < chatRecentCatches; i-- {
+		recent = append(recent, chatPokemon(user.Pokemon[i]))
+	}
+	if len(recent) > 0 {
+		text += "\nRecent: " + strings.Join(recent, ", ")
+	}
+
+	return ephemeral(text), nil
+}
+
+func (c ChatHandler) leaderboard(logger lager.Logger, user *models.User, now time.Time) (ChatResponse, error) {
+	leaderboard, err := currentLeaderboard(logger, c.d, c.calendar, user.Team, now)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	title := "Everyone"
+	if user.Team != "" {
+		title = user.Team
+	}
+
+	lines := []string{fmt.Sprintf("*%s leaderboard*", title)}
+	for i, entry := range leaderboard {
+		if i == chatLeaderboardSize {
+			break
+		}
+
+		lines = append(lines, fmt.Sprintf("%d. %s - %d unique, %d caught, %d day streak", i+1, entry.Username, entry.Unique, entry.Caught, entry.Streak.Current))
+	}
+
+	return ChatResponse{ResponseType: "in_channel", Text: strings.Join(lines, "\n")}, nil
+}
+
+// trade proposes swapping one of the user's Pokemon for one of another
+// user's: `trade @bob 25 for 4` offers a #25 for bob's #4.
+func (c ChatHandler) trade(logger lager.Logger, user *models.User, args []string, now time.Time) (ChatResponse, error) {
+	if len(args) != 4 || args[2] != "for" {
+		return ephemeral(chatUsage), nil
+	}
+
+	offered, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+	if err != nil {
+		return ephemeral(chatUsage), nil
+	}
+	requested, err := strconv.Atoi(strings.TrimPrefix(args[3], "#"))
+	if err != nil {
+		return ephemeral(chatUsage), nil
+	}
+
+	recipient, err := c.mentionedUser(logger, args[0])
+	if err == db.ResourceNotFound {
+		return ephemeral(fmt.Sprintf("%s is not a registered trainer.", args[0])), nil
+	}
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	if recipient.Username == user.Username {
+		return ephemeral("You cannot trade with yourself."), nil
+	}
+
+	trade, err := c.d.CreateTrade(logger, models.Trade{
+		Proposer:  user.Username,
+		Recipient: recipient.Username,
+		Offered:   models.Pokemon{Index: offered},
+		Requested: models.Pokemon{Index: requested},
+		CreatedAt: now,
+	})
+	if err == db.ErrPokemonNotOwned {
+		return ephemeral(fmt.Sprintf("Both trainers need the Pokemon they are trading: you #%d, %s #%d.", offered, recipient.Username, requested)), nil
+	}
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	return ChatResponse{
+		ResponseType: "in_channel",
+		Text: fmt.Sprintf("%s offers %s for %s's %s. %s, reply `/pokedex accept %d` to trade.",
+			user.Username, chatPokemon(trade.Offered), recipient.Username, chatPokemon(trade.Requested), chatMention(recipient), trade.ID),
+	}, nil
+}
+
+func (c ChatHandler) accept(logger lager.Logger, user *models.User, args []string, now time.Time) (ChatResponse, error) {
+	if len(args) != 1 {
+		return ephemeral(chatUsage), nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return ephemeral(chatUsage), nil
+	}
+
+	trade, err := c.d.AcceptTrade(logger, id, user.Username, now)
+	if err == db.ResourceNotFound {
+		return ephemeral(fmt.Sprintf("You have no pending trade #%d.", id)), nil
+	}
+	if err == db.ErrPokemonNotOwned {
+		return ephemeral(fmt.Sprintf("Trade #%d can no longer happen: one of the Pokemon has left its trainer.", id)), nil
+	}
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	c.publisher.Publish(logger, models.GameEvent{
+		Type:       models.GameEventTrade,
+		Username:   user.Username,
+		Team:       user.Team,
+		OccurredAt: now,
+		Trade:      trade,
+	})
+
+	return ChatResponse{
+		ResponseType: "in_channel",
+		Text: fmt.Sprintf("Trade #%d complete: %s sent %s to %s for %s.",
+			trade.ID, trade.Proposer, chatPokemon(trade.Offered), trade.Recipient, chatPokemon(trade.Requested)),
+	}, nil
+}
+
+// mentionedUser resolves an escaped Slack mention (<@U123|bob>) by chat user
+// ID, or a plain @name by username.
+func (c ChatHandler) mentionedUser(logger lager.Logger, mention string) (*models.User, error) {
+	if strings.HasPrefix(mention, "<@") && strings.HasSuffix(mention, ">") {
+		id := strings.TrimSuffix(strings.TrimPrefix(mention, "<@"), ">")
+		if i := strings.Index(id, "|"); i >= 0 {
+			id = id[:i]
+		}
+		return c.d.GetUserByChatID(logger, id)
+	}
+
+	return c.d.GetUser(logger, strings.TrimPrefix(mention, "@"))
+}
+
+func chatMention(user *models.User) string {
+	if user.ChatUserID == "" {
+		return user.Username
+	}
+	return "<@" + user.ChatUserID + ">"
+}
+
+func chatPokemon(pokemon models.Pokemon) string {
+	text := fmt.Sprintf("#%d %s", pokemon.Index, pokemon.Name)
+	if pokemon.Tier != "" {
+		text += fmt.Sprintf(" (%s)", pokemon.Tier)
+	}
+	return text
+}
+
+func ephemeral(text string) ChatResponse {
+	return ChatResponse{ResponseType: "ephemeral", Text: text}
+}
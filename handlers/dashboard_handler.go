@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/dashboard"
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/pivotal-golang/lager"
+)
+
+const dashboardRecentCatches = 20
+
+type DashboardHandler struct {
+	logger      lager.Logger
+	d           *db.DB
+	calendar    streak.Calendar
+	pools       *encounter.Pools
+	defaultPool string
+	pages       *dashboard.Pages
+}
+
+func NewDashboardHandler(logger lager.Logger, d *db.DB, calendar streak.Calendar, pools *encounter.Pools, defaultPool string, pages *dashboard.Pages) DashboardHandler {
+	return DashboardHandler{logger, d, calendar, pools, defaultPool, pages}
+}
+
+type dashboardIndex struct {
+	Feed        string
+	Leaderboard []models.LeaderboardEntry
+	Teams       []*models.Team
+	Recent      []models.GameEvent
+}
+
+type dashboardUser struct {
+	Feed   string
+	User   *models.User
+	Unique int
+	Dex    []models.TeamPokemon
+	Recent []models.GameEvent
+}
+
+type dashboardTeam struct {
+	Feed        string
+	Team        *models.Team
+	Unique      int
+	Leaderboard []models.LeaderboardEntry
+	Dex         []models.TeamPokemon
+	Recent      []models.GameEvent
+}
+
+func (h DashboardHandler) Index(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("dashboard-index")
+
+	leaderboard, err := currentLeaderboard(logger, h.d, h.calendar, "", time.Now())
+	if err != nil {
+		logger.Error("failed-to-get-leaderboard", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	teams, err := h.d.Teams(logger)
+	if err != nil {
+		logger.Error("failed-to-list-teams", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	recent, err := recentCatches(logger, h.d, h.pools, "", "", dashboardRecentCatches)
+	if err != nil {
+		logger.Error("failed-to-get-recent-catches", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.render(logger, w, "index.html", dashboardIndex{
+		Feed:        "/v1/feed",
+		Leaderboard: leaderboard,
+		Teams:       teams,
+		Recent:      recent,
+	})
+}
+
+func (h DashboardHandler) User(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("dashboard-user")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := currentUser(logger, h.d, h.calendar, username, time.Now())
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-user", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	pool := h.defaultPool
+	if user.Team != "" {
+		team, err := h.d.GetTeam(logger, user.Team)
+		if err != nil && err != db.ResourceNotFound {
+			logger.Error("failed-to-get-team", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if team != nil && team.Pool != "" {
+			pool = team.Pool
+		}
+	}
+
+	caught := []models.TeamPokemon{}
+	for _, pokemon := range user.Pokemon {
+		caught = append(caught, models.TeamPokemon{Index: pokemon.Index, Name: pokemon.Name, Tier: pokemon.Tier, Count: 1})
+	}
+	dex, unique := h.dex(pool, caught)
+
+	recent, err := recentCatches(logger, h.d, h.pools, username, "", dashboardRecentCatches)
+	if err != nil {
+		logger.Error("failed-to-get-recent-catches", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.render(logger, w, "user.html", dashboardUser{
+		Feed:   "/v1/feed?" + url.Values{"username": {username}}.Encode(),
+		User:   user,
+		Unique: unique,
+		Dex:    dex,
+		Recent: recent,
+	})
+}
+
+func (h DashboardHandler) Team(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("dashboard-team")
+
+	name := req.FormValue(":team")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	team, caught, err := teamPokedex(logger, h.d, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-team-pokedex", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	leaderboard, err := currentLeaderboard(logger, h.d, h.calendar, name, time.Now())
+	if err != nil {
+		logger.Error("failed-to-get-leaderboard", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	pool := team.Pool
+	if pool == "" {
+		pool = h.defaultPool
+	}
+	dex, unique := h.dex(pool, caught)
+
+	recent, err := recentCatches(logger, h.d, h.pools, "", name, dashboardRecentCatches)
+	if err != nil {
+		logger.Error("failed-to-get-recent-catches", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.render(logger, w, "team.html", dashboardTeam{
+		Feed:        "/v1/feed?" + url.Values{"team": {name}}.Encode(),
+		Team:        team,
+		Unique:      unique,
+		Leaderboard: leaderboard,
+		Dex:         dex,
+		Recent:      recent,
+	})
+}
+
+// dex lays the caught species over every species in pool, so the ones still
+// to catch show up as silhouettes. Species caught outside the pool are kept.
+func (h DashboardHandler) dex(pool string, caught []models.TeamPokemon) ([]models.TeamPokemon, int) {
+	byIndex := map[int]*models.TeamPokemon{}
+	if table, ok := h.pools.Get(pool); ok {
+		for _, entry := range table.Entries() {
+			byIndex[entry.Index] = &models.TeamPokemon{Index: entry.Index, Name: entry.Name, Tier: entry.Tier}
+		}
+	}
+
+	for _, pokemon := range caught {
+		entry, ok := byIndex[pokemon.Index]
+		if !ok {
+			entry = &models.TeamPokemon{Index: pokemon.Index, Name: pokemon.Name, Tier: pokemon.Tier}
+			byIndex[pokemon.Index] = entry
+		}
+		entry.Count += pokemon.Count
+	}
+
+	dex := []models.TeamPokemon{}
+	unique := 0
+	for _, entry := range byIndex {
+		if entry.Count > 0 {
+			unique++
+		}
+		dex = append(dex, *entry)
+	}
+
+	sort.Slice(dex, func(i, j int) bool { return dex[i].Index < dex[j].Index })
+	return dex, unique
+}
+
+// render buffers the page so a template error becomes a 500 rather than a
+// half-written page.
+func (h DashboardHandler) render(logger lager.Logger, w http.ResponseWriter, page string, data interface{}) {
+	var buf bytes.Buffer
+	err := h.pages.Render(&buf, page, data)
+	if err != nil {
+		logger.Error("failed-rendering-page", err, lager.Data{"page": page})
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
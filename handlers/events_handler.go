@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+type EventsHandler struct {
+	logger lager.Logger
+	d      *db.DB
+	pools  *encounter.Pools
+}
+
+func NewEventsHandler(logger lager.Logger, d *db.DB, pools *encounter.Pools) EventsHandler {
+	return EventsHandler{logger, d, pools}
+}
+
+type CreateEventRequest struct {
+	Name       string    `json:"name"`
+	Team       string    `json:"team"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Species    []int     `json:"species"`
+	Tiers      []string  `json:"tiers"`
+	Types      []string  `json:"types"`
+	Multiplier float64   `json:"multiplier"`
+}
+
+// Validate checks the event against the configured pools, so a misspelt
+// tier or a species no pool can roll is rejected rather than boosting
+// nothing. The catalog carries no Pokemon types, so an event boosting types
+// is rejected too.
+func (r CreateEventRequest) Validate(pools *encounter.Pools) bool {
+	if r.Name == "" || !r.EndsAt.After(r.StartsAt) {
+		return false
+	}
+
+	if len(r.Types) > 0 {
+		return false
+	}
+
+	known := map[string]bool{}
+	for _, tier := range pools.Tiers() {
+		known[tier.Name] = true
+	}
+	for _, tier := range r.Tiers {
+		if !known[tier] {
+			return false
+		}
+	}
+
+	rollable := map[int]bool{}
+	for _, config := range pools.Configs() {
+		table, ok := pools.Get(config.ID)
+		if !ok {
+			continue
+		}
+		for _, entry := range table.Entries() {
+			rollable[entry.Index] = true
+		}
+	}
+	for _, index := range r.Species {
+		if !rollable[index] {
+			return false
+		}
+	}
+
+	boost := encounter.Boost{Species: r.Species, Tiers: r.Tiers, Multiplier: r.Multiplier}
+	return boost.Validate() == nil
+}
+
+func (e EventsHandler) CreateEvent(w http.ResponseWriter, req *http.Request) {
+	logger := e.logger.Session("create-event")
+
+	request := &CreateEventRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !request.Validate(e.pools) {
+		logger.Info("invalid-event", lager.Data{"name": request.Name})
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Team != "" {
+		_, err = e.d.GetTeam(logger, request.Team)
+		if err == db.ResourceNotFound {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error("failed-to-get-team", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	event := models.Event{
+		Name:       request.Name,
+		Team:       request.Team,
+		StartsAt:   request.StartsAt,
+		EndsAt:     request.EndsAt,
+		Species:    request.Species,
+		Tiers:      request.Tiers,
+		Multiplier: request.Multiplier,
+	}
+
+	event.ID, err = e.d.CreateEvent(logger, event)
+	if err != nil {
+		logger.Error("failed-to-create-event", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data, err = json.Marshal(&event)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (e EventsHandler) ListEvents(w http.ResponseWriter, req *http.Request) {
+	logger := e.logger.Session("list-events")
+
+	events, err := e.d.EventsEndingAfter(logger, time.Now())
+	if err != nil {
+		logger.Error("failed-to-list-events", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (e EventsHandler) DeleteEvent(w http.ResponseWriter, req *http.Request) {
+	logger := e.logger.Session("delete-event")
+
+	id, err := strconv.Atoi(req.FormValue(":id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = e.d.DeleteEvent(logger, id)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-delete-event", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
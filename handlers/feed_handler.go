@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/feed"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/webhooks"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	feedReplayLimit = 500
+	feedKeepalive   = 15 * time.Second
+
+	// FeedReplayTruncated is sent when more catches were missed than one
+	// replay sends. The stream then ends so the client reconnects from the
+	// last catch it was sent and is replayed the rest.
+	FeedReplayTruncated = "replay-truncated"
+)
+
+type FeedHandler struct {
+	logger lager.Logger
+	d      *db.DB
+	broker *feed.Broker
+	pools  *encounter.Pools
+}
+
+func NewFeedHandler(logger lager.Logger, d *db.DB, broker *feed.Broker, pools *encounter.Pools) FeedHandler {
+	return FeedHandler{logger, d, broker, pools}
+}
+
+// Feed streams game events as Server-Sent Events, optionally limited to a
+// username or team. Catch events carry their feed sequence number, which
+// follows commit order, as the event ID; a client reconnecting with
+// Last-Event-ID (or ?last_event_id=, for clients that cannot set headers) is
+// first sent the catches it missed.
+func (f FeedHandler) Feed(w http.ResponseWriter, req *http.Request) {
+	filter := feed.Filter{
+		Username: req.FormValue("username"),
+		Team:     req.FormValue("team"),
+	}
+	logger := f.logger.Session("feed", lager.Data{"username": filter.Username, "team": filter.Team})
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Info("streaming-unsupported")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := req.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = req.FormValue("last_event_id")
+	}
+
+	afterSequence := -1
+	if lastEventID != "" {
+		var err error
+		afterSequence, err = strconv.Atoi(lastEventID)
+		if err != nil || afterSequence < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Subscribe before replaying so nothing published in between is lost;
+	// catches already replayed are skipped when they arrive live.
+	subscription := f.broker.Subscribe(filter)
+	defer f.broker.Unsubscribe(subscription)
+
+	replayed := afterSequence
+	truncated := false
+	var missed []models.GameEvent
+	if afterSequence >= 0 {
+		var err error
+		missed, err = f.d.CatchesAfter(logger, afterSequence, filter.Username, filter.Team, feedReplayLimit+1)
+		if err != nil {
+			logger.Error("failed-to-replay-catches", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if len(missed) > feedReplayLimit {
+			missed = missed[:feedReplayLimit]
+			truncated = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	tiers := newTierLookup(f.pools)
+	for _, event := range missed {
+		event.Catch.Pokemon.Tier = tiers.tier(event.Catch.Pokemon)
+		err := writeFeedEvent(w, event)
+		if err != nil {
+			return
+		}
+		replayed = event.Catch.FeedSequence
+	}
+
+	if truncated {
+		logger.Info("replay-truncated", lager.Data{"last-event-id": replayed})
+		fmt.Fprintf(w, "event: %s\ndata: {\"last_event_id\":\"%d\"}\n\n", FeedReplayTruncated, replayed)
+		flusher.Flush()
+		return
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(feedKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-keepalive.C:
+			_, err := fmt.Fprint(w, ": keepalive\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-subscription.Events:
+			if !ok {
+				logger.Info("subscription-closed")
+				return
+			}
+			if event.Catch != nil && event.Catch.FeedSequence <= replayed {
+				continue
+			}
+
+			err := writeFeedEvent(w, event)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// tierLookup fills in the tiers of Pokemon read back from the catch log,
+// which does not record them, from their pools.
+type tierLookup struct {
+	pools *encounter.Pools
+	pool  map[string]map[int]string
+}
+
+func newTierLookup(pools *encounter.Pools) tierLookup {
+	return tierLookup{pools: pools, pool: map[string]map[int]string{}}
+}
+
+func (t tierLookup) tier(pokemon models.Pokemon) string {
+	byIndex, ok := t.pool[pokemon.Pool]
+	if !ok {
+		byIndex = map[int]string{}
+		if table, ok := t.pools.Get(pokemon.Pool); ok {
+			for _, entry := range table.Entries() {
+				byIndex[entry.Index] = entry.Tier
+			}
+		}
+		t.pool[pokemon.Pool] = byIndex
+	}
+
+	return byIndex[pokemon.Index]
+}
+
+func writeFeedEvent(w http.ResponseWriter, event models.GameEvent) error {
+	data, err := json.Marshal(webhooks.NewPayload(event))
+	if err != nil {
+		return err
+	}
+
+	if event.Catch != nil {
+		_, err = fmt.Fprintf(w, "id: %d\n", event.Catch.FeedSequence)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -1,23 +1,127 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/jfmyers9/gotta-track-em-all/dashboard"
 	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/feed"
+	"github.com/jfmyers9/gotta-track-em-all/metrics"
 	"github.com/jfmyers9/gotta-track-em-all/routes"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
 	"github.com/pivotal-golang/lager"
 	"github.com/tedsuo/rata"
 )
 
-func NewHandler(logger lager.Logger, d *db.DB) (http.Handler, error) {
-	usersHandler := NewUsersHandler(logger, d)
+type Watcher interface {
+	Syncer
+	CycleReporter
+}
+
+// Config holds what the handlers need beyond the database.
+type Config struct {
+	Watcher           Watcher
+	StaleAfter        time.Duration
+	Calendar          streak.Calendar
+	Pools             *encounter.Pools
+	DefaultPool       string
+	Pages             *dashboard.Pages
+	TrackerClient     *tracker.Client
+	LogLevel          LevelSetter
+	Publisher         Publisher
+	Broker            *feed.Broker
+	AdminToken        string
+	ChatSigningSecret string
+}
+
+func NewHandler(logger lager.Logger, d *db.DB, config Config) (http.Handler, error) {
+	usersHandler := NewUsersHandler(logger, d, config.Calendar)
+	syncHandler := NewSyncHandler(logger, d, config.Watcher)
+	eventsHandler := NewEventsHandler(logger, d, config.Pools)
+	teamsHandler := NewTeamsHandler(logger, d, config.Calendar, config.Pools)
+	projectsHandler := NewProjectsHandler(logger, d, config.TrackerClient)
+	healthHandler := NewHealthHandler(logger, d, config.Watcher, config.StaleAfter)
+	logLevelHandler := NewLogLevelHandler(logger, config.LogLevel)
+	webhooksHandler := NewWebhooksHandler(logger, d)
+	chatHandler := NewChatHandler(logger, d, config.Calendar, config.Publisher, config.ChatSigningSecret)
+	feedHandler := NewFeedHandler(logger, d, config.Broker, config.Pools)
+	dashboardHandler := NewDashboardHandler(logger, d, config.Calendar, config.Pools, config.DefaultPool, config.Pages)
+	adminToken := config.AdminToken
 
 	handlers := rata.Handlers{
 		routes.CreateUser: http.HandlerFunc(usersHandler.CreateUser),
 		routes.GetUser:    http.HandlerFunc(usersHandler.GetUser),
 		routes.UpdateUser: http.HandlerFunc(usersHandler.UpdateUser),
 		routes.DeleteUser: http.HandlerFunc(usersHandler.DeleteUser),
+
+		routes.GetUserStatus: http.HandlerFunc(usersHandler.GetUserStatus),
+		routes.SyncUser:      http.HandlerFunc(syncHandler.SyncUser),
+
+		routes.GetUserEncounters: http.HandlerFunc(usersHandler.GetUserEncounters),
+
+		routes.GetUserProjects: requireAdmin(logger, adminToken, http.HandlerFunc(projectsHandler.GetUserProjects)),
+		routes.SetUserProjects: requireAdmin(logger, adminToken, http.HandlerFunc(projectsHandler.SetUserProjects)),
+
+		routes.Sync: requireAdmin(logger, adminToken, http.HandlerFunc(syncHandler.Sync)),
+
+		routes.CreateEvent: requireAdmin(logger, adminToken, http.HandlerFunc(eventsHandler.CreateEvent)),
+		routes.ListEvents:  http.HandlerFunc(eventsHandler.ListEvents),
+		routes.DeleteEvent: requireAdmin(logger, adminToken, http.HandlerFunc(eventsHandler.DeleteEvent)),
+
+		routes.CreateTeam:         requireAdmin(logger, adminToken, http.HandlerFunc(teamsHandler.CreateTeam)),
+		routes.ListTeams:          http.HandlerFunc(teamsHandler.ListTeams),
+		routes.GetTeam:            http.HandlerFunc(teamsHandler.GetTeam),
+		routes.UpdateTeam:         requireAdmin(logger, adminToken, http.HandlerFunc(teamsHandler.UpdateTeam)),
+		routes.DeleteTeam:         requireAdmin(logger, adminToken, http.HandlerFunc(teamsHandler.DeleteTeam)),
+		routes.JoinTeam:           requireAdmin(logger, adminToken, http.HandlerFunc(teamsHandler.JoinTeam)),
+		routes.LeaveTeam:          requireAdmin(logger, adminToken, http.HandlerFunc(teamsHandler.LeaveTeam)),
+		routes.GetTeamPokedex:     http.HandlerFunc(teamsHandler.GetTeamPokedex),
+		routes.GetTeamLeaderboard: http.HandlerFunc(teamsHandler.GetTeamLeaderboard),
+
+		routes.SetTeamAnnouncements: requireAdmin(logger, adminToken, http.HandlerFunc(teamsHandler.SetTeamAnnouncements)),
+
+		routes.SetChatUserID: requireAdmin(logger, adminToken, http.HandlerFunc(usersHandler.SetChatUserID)),
+		routes.ChatCommand:   http.HandlerFunc(chatHandler.Command),
+
+		routes.Feed: http.HandlerFunc(feedHandler.Feed),
+
+		routes.Dashboard:     http.HandlerFunc(dashboardHandler.Index),
+		routes.DashboardUser: http.HandlerFunc(dashboardHandler.User),
+		routes.DashboardTeam: http.HandlerFunc(dashboardHandler.Team),
+
+		routes.CreateWebhook:         requireAdmin(logger, adminToken, http.HandlerFunc(webhooksHandler.CreateWebhook)),
+		routes.ListWebhooks:          requireAdmin(logger, adminToken, http.HandlerFunc(webhooksHandler.ListWebhooks)),
+		routes.DeleteWebhook:         requireAdmin(logger, adminToken, http.HandlerFunc(webhooksHandler.DeleteWebhook)),
+		routes.ListWebhookDeliveries: requireAdmin(logger, adminToken, http.HandlerFunc(webhooksHandler.ListWebhookDeliveries)),
+		routes.RetryWebhookDelivery:  requireAdmin(logger, adminToken, http.HandlerFunc(webhooksHandler.RetryWebhookDelivery)),
+
+		routes.GetLogLevel: requireAdmin(logger, adminToken, http.HandlerFunc(logLevelHandler.GetLogLevel)),
+		routes.SetLogLevel: requireAdmin(logger, adminToken, http.HandlerFunc(logLevelHandler.SetLogLevel)),
+
+		routes.Metrics: metrics.Handler(),
+		routes.Healthz: http.HandlerFunc(healthHandler.Healthz),
+		routes.Readyz:  http.HandlerFunc(healthHandler.Readyz),
+	}
+
+	for route, handler := range handlers {
+		handlers[route] = metrics.InstrumentRoute(route, handler)
 	}
 
 	return rata.NewRouter(routes.Routes, handlers)
 }
+
+func writeJSON(logger lager.Logger, w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
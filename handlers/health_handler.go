@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/pivotal-golang/lager"
+)
+
+type CycleReporter interface {
+	LastCycleCompleted() time.Time
+}
+
+type HealthHandler struct {
+	logger     lager.Logger
+	d          *db.DB
+	cycles     CycleReporter
+	staleAfter time.Duration
+}
+
+// NewHealthHandler reports the server unready once the watcher has gone
+// staleAfter without completing a poll.
+func NewHealthHandler(logger lager.Logger, d *db.DB, cycles CycleReporter, staleAfter time.Duration) HealthHandler {
+	return HealthHandler{logger, d, cycles, staleAfter}
+}
+
+func (h HealthHandler) Healthz(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// Readyz reports each check as "ok" or its failure, responding 503 if any
+// check failed.
+func (h HealthHandler) Readyz(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("readyz")
+
+	checks := map[string]string{
+		"database":   "ok",
+		"migrations": "ok",
+		"watcher":    "ok",
+	}
+	ready := true
+
+	err := h.d.Ping()
+	if err != nil {
+		logger.Error("failed-to-ping-database", err)
+		checks["database"] = err.Error()
+		checks["migrations"] = "unknown"
+		ready = false
+	} else {
+		err = h.d.CheckVersion(logger)
+		if err != nil {
+			logger.Error("failed-to-check-migrations", err)
+			checks["migrations"] = err.Error()
+			ready = false
+		}
+	}
+
+	lastCycle := h.cycles.LastCycleCompleted()
+	if lastCycle.IsZero() || time.Since(lastCycle) > h.staleAfter {
+		logger.Info("watcher-stalled", lager.Data{"last-cycle-completed": lastCycle})
+		checks["watcher"] = "no poll completed since " + lastCycle.Format(time.RFC3339)
+		ready = false
+	}
+
+	data, err := json.Marshal(checks)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(data)
+}
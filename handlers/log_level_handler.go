@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jfmyers9/gotta-track-em-all/logging"
+	"github.com/pivotal-golang/lager"
+)
+
+type LevelSetter interface {
+	SetMinLevel(lager.LogLevel)
+	GetMinLevel() lager.LogLevel
+}
+
+type LogLevelHandler struct {
+	logger lager.Logger
+	sink   LevelSetter
+}
+
+func NewLogLevelHandler(logger lager.Logger, sink LevelSetter) LogLevelHandler {
+	return LogLevelHandler{logger, sink}
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func (l LogLevelHandler) GetLogLevel(w http.ResponseWriter, req *http.Request) {
+	logger := l.logger.Session("get-log-level")
+
+	writeJSON(logger, w, LogLevelRequest{Level: logging.LevelName(l.sink.GetMinLevel())})
+}
+
+func (l LogLevelHandler) SetLogLevel(w http.ResponseWriter, req *http.Request) {
+	logger := l.logger.Session("set-log-level")
+
+	request := &LogLevelRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	level, err := logging.ParseLevel(request.Level)
+	if err != nil {
+		logger.Error("invalid-log-level", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("changing-log-level", lager.Data{"from": logging.LevelName(l.sink.GetMinLevel()), "to": logging.LevelName(level)})
+	l.sink.SetMinLevel(level)
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
+	"github.com/pivotal-golang/lager"
+)
+
+type ProjectsHandler struct {
+	logger        lager.Logger
+	d             *db.DB
+	trackerClient *tracker.Client
+}
+
+func NewProjectsHandler(logger lager.Logger, d *db.DB, trackerClient *tracker.Client) ProjectsHandler {
+	return ProjectsHandler{logger, d, trackerClient}
+}
+
+type SetProjectsRequest struct {
+	TrackerProjectIDs []int `json:"tracker_project_ids"`
+}
+
+func (p ProjectsHandler) SetUserProjects(w http.ResponseWriter, req *http.Request) {
+	logger := p.logger.Session("set-user-projects")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	request := &SetProjectsRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = p.d.SetUserProjects(logger, username, request.TrackerProjectIDs)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-set-user-projects", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetUserProjects lists the user's Tracker projects so they can pick an
+// allowlist, marking the ones that currently earn catches.
+func (p ProjectsHandler) GetUserProjects(w http.ResponseWriter, req *http.Request) {
+	logger := p.logger.Session("get-user-projects")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := p.d.GetUser(logger, username)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-user", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	allowed := user.TrackerProjectIDs
+	if len(allowed) == 0 && user.Team != "" {
+		team, err := p.d.GetTeam(logger, user.Team)
+		if err != nil && err != db.ResourceNotFound {
+			logger.Error("failed-to-get-team", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if team != nil {
+			allowed = team.TrackerProjectIDs
+		}
+	}
+
+	trackerProjects, err := p.trackerClient.Projects(logger, user.TrackerAPIToken)
+	if err != nil {
+		logger.Error("failed-to-fetch-tracker-projects", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	projects := []models.Project{}
+	for _, project := range trackerProjects {
+		projects = append(projects, models.Project{
+			ID:      project.ID,
+			Name:    project.Name,
+			Allowed: len(allowed) == 0 || containsInt(allowed, project.ID),
+		})
+	}
+
+	writeJSON(logger, w, projects)
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
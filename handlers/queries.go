@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/pivotal-golang/lager"
+)
+
+// These build the views shared by the JSON API, chat commands and the
+// dashboard, so each shows the same numbers.
+
+// currentUser reads a user with their streak as of now.
+func currentUser(logger lager.Logger, d *db.DB, calendar streak.Calendar, username string, now time.Time) (*models.User, error) {
+	user, err := d.GetUser(logger, username)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Streak = streak.Current(calendar, user.Streak, now)
+	return user, nil
+}
+
+// currentLeaderboard ranks a team's members, or everyone when team is empty,
+// with streaks as of now.
+func currentLeaderboard(logger lager.Logger, d *db.DB, calendar streak.Calendar, team string, now time.Time) ([]models.LeaderboardEntry, error) {
+	leaderboard, err := d.Leaderboard(logger, team)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range leaderboard {
+		leaderboard[i].Streak = streak.Current(calendar, leaderboard[i].Streak, now)
+	}
+
+	return leaderboard, nil
+}
+
+// teamPokedex reads a team and what its members have caught, returning
+// db.ResourceNotFound if there is no such team.
+func teamPokedex(logger lager.Logger, d *db.DB, name string) (*models.Team, []models.TeamPokemon, error) {
+	team, err := d.GetTeam(logger, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dex, err := d.TeamPokedex(logger, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return team, dex, nil
+}
+
+// recentCatches reads the latest catches, newest first, with their tiers
+// filled in from their pools.
+func recentCatches(logger lager.Logger, d *db.DB, pools *encounter.Pools, username, team string, limit int) ([]models.GameEvent, error) {
+	recent, err := d.RecentCatches(logger, username, team, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers := newTierLookup(pools)
+	for _, event := range recent {
+		event.Catch.Pokemon.Tier = tiers.tier(event.Catch.Pokemon)
+	}
+
+	return recent, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/pivotal-golang/lager"
+)
+
+type Syncer interface {
+	Sync(username string) error
+}
+
+type SyncHandler struct {
+	logger lager.Logger
+	d      *db.DB
+	syncer Syncer
+}
+
+func NewSyncHandler(logger lager.Logger, d *db.DB, syncer Syncer) SyncHandler {
+	return SyncHandler{logger, d, syncer}
+}
+
+func (s SyncHandler) Sync(w http.ResponseWriter, req *http.Request) {
+	logger := s.logger.Session("sync")
+
+	err := s.syncer.Sync("")
+	if err != nil {
+		logger.Error("failed-to-request-sync", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s SyncHandler) SyncUser(w http.ResponseWriter, req *http.Request) {
+	logger := s.logger.Session("sync-user")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, err := s.d.GetUser(logger, username)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-user", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = s.syncer.Sync(username)
+	if err != nil {
+		logger.Error("failed-to-request-sync", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
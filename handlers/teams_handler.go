@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/pivotal-golang/lager"
+)
+
+type TeamsHandler struct {
+	logger   lager.Logger
+	d        *db.DB
+	calendar streak.Calendar
+	pools    *encounter.Pools
+}
+
+func NewTeamsHandler(logger lager.Logger, d *db.DB, calendar streak.Calendar, pools *encounter.Pools) TeamsHandler {
+	return TeamsHandler{logger, d, calendar, pools}
+}
+
+type CreateTeamRequest struct {
+	Name              string `json:"name"`
+	TrackerProjectIDs []int  `json:"tracker_project_ids"`
+	Pool              string `json:"pool"`
+	Attribution       string `json:"attribution"`
+}
+
+type SetTeamAnnouncementsRequest struct {
+	SlackWebhookURL string `json:"slack_webhook_url"`
+	MinTier         string `json:"min_tier"`
+}
+
+// UpdateTeamRequest changes only the fields that are present, leaving the
+// rest of the team as it is.
+type UpdateTeamRequest struct {
+	TrackerProjectIDs *[]int  `json:"tracker_project_ids,omitempty"`
+	Pool              *string `json:"pool,omitempty"`
+	Attribution       *string `json:"attribution,omitempty"`
+}
+
+func (t TeamsHandler) validPool(pool string) bool {
+	if pool == "" {
+		return true
+	}
+
+	_, ok := t.pools.Get(pool)
+	return ok
+}
+
+// attribution defaults to crediting notification recipients. Crediting story
+// owners reads project activity, so it needs projects to read from.
+func attribution(mode string, projectIDs []int) (string, bool) {
+	switch mode {
+	case "", models.AttributionRecipient:
+		return models.AttributionRecipient, true
+	case models.AttributionOwners:
+		return mode, len(projectIDs) > 0
+	default:
+		return "", false
+	}
+}
+
+func (t TeamsHandler) CreateTeam(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("create-team")
+
+	request := &CreateTeamRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	mode, ok := attribution(request.Attribution, request.TrackerProjectIDs)
+	if request.Name == "" || !t.validPool(request.Pool) || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = t.d.CreateTeam(logger, models.Team{
+		Name:              request.Name,
+		TrackerProjectIDs: request.TrackerProjectIDs,
+		Pool:              request.Pool,
+		Attribution:       mode,
+	})
+	if err != nil {
+		logger.Error("failed-to-create-team", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t TeamsHandler) ListTeams(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("list-teams")
+
+	teams, err := t.d.Teams(logger)
+	if err != nil {
+		logger.Error("failed-to-list-teams", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for _, team := range teams {
+		team.Announcements.SlackWebhookURL = ""
+	}
+
+	writeJSON(logger, w, teams)
+}
+
+func (t TeamsHandler) GetTeam(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("get-team")
+
+	name := req.FormValue(":team")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	team, err := t.d.GetTeam(logger, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-team", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	team.Announcements.SlackWebhookURL = ""
+	writeJSON(logger, w, team)
+}
+
+func (t TeamsHandler) UpdateTeam(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("update-team")
+
+	request := &UpdateTeamRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name := req.FormValue(":team")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	team, err := t.d.GetTeam(logger, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-team", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if request.TrackerProjectIDs != nil {
+		team.TrackerProjectIDs = *request.TrackerProjectIDs
+	}
+	if request.Pool != nil {
+		team.Pool = *request.Pool
+	}
+	if request.Attribution != nil {
+		team.Attribution = *request.Attribution
+	}
+
+	mode, ok := attribution(team.Attribution, team.TrackerProjectIDs)
+	if !t.validPool(team.Pool) || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = t.d.UpdateTeam(logger, models.Team{
+		Name:              name,
+		TrackerProjectIDs: team.TrackerProjectIDs,
+		Pool:              team.Pool,
+		Attribution:       mode,
+	})
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-update-team", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t TeamsHandler) validTier(tier string) bool {
+	if tier == "" {
+		return true
+	}
+
+	for _, config := range t.pools.Tiers() {
+		if config.Name == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTeamAnnouncements points the team's catches at a Slack incoming webhook.
+// An empty URL turns announcements off.
+func (t TeamsHandler) SetTeamAnnouncements(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("set-team-announcements")
+
+	request := &SetTeamAnnouncementsRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name := req.FormValue(":team")
+	if name == "" || !t.validTier(request.MinTier) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.SlackWebhookURL != "" {
+		u, err := url.Parse(request.SlackWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = t.d.SetTeamAnnouncements(logger, name, models.Announcements{
+		SlackWebhookURL: request.SlackWebhookURL,
+		MinTier:         request.MinTier,
+	})
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-set-team-announcements", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t TeamsHandler) DeleteTeam(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("delete-team")
+
+	name := req.FormValue(":team")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := t.d.DeleteTeam(logger, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-delete-team", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t TeamsHandler) JoinTeam(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("join-team")
+
+	name := req.FormValue(":team")
+	username := req.FormValue(":username")
+	if name == "" || username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := t.d.JoinTeam(logger, username, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-join-team", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t TeamsHandler) LeaveTeam(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("leave-team")
+
+	name := req.FormValue(":team")
+	username := req.FormValue(":username")
+	if name == "" || username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := t.d.LeaveTeam(logger, username, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-leave-team", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t TeamsHandler) GetTeamPokedex(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("get-team-pokedex")
+
+	name := req.FormValue(":team")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, dex, err := teamPokedex(logger, t.d, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-team-pokedex", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(logger, w, dex)
+}
+
+func (t TeamsHandler) GetTeamLeaderboard(w http.ResponseWriter, req *http.Request) {
+	logger := t.logger.Session("get-team-leaderboard")
+
+	name := req.FormValue(":team")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, err := t.d.GetTeam(logger, name)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-team", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	leaderboard, err := currentLeaderboard(logger, t.d, t.calendar, name, time.Now())
+	if err != nil {
+		logger.Error("failed-to-get-leaderboard", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(logger, w, leaderboard)
+}
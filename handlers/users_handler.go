@@ -4,18 +4,21 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
 	"github.com/pivotal-golang/lager"
 )
 
 type UsersHandler struct {
-	logger lager.Logger
-	d      *db.DB
+	logger   lager.Logger
+	d        *db.DB
+	calendar streak.Calendar
 }
 
-func NewUsersHandler(logger lager.Logger, d *db.DB) UsersHandler {
-	return UsersHandler{logger, d}
+func NewUsersHandler(logger lager.Logger, d *db.DB, calendar streak.Calendar) UsersHandler {
+	return UsersHandler{logger, d, calendar}
 }
 
 type CreateRequest struct {
@@ -61,7 +64,7 @@ func (u UsersHandler) GetUser(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	user, err := u.d.GetUser(logger, username)
+	user, err := currentUser(logger, u.d, u.calendar, username, time.Now())
 	if err != nil {
 		logger.Error("failed-to-get-user", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -136,3 +139,106 @@ func (u UsersHandler) DeleteUser(w http.ResponseWriter, req *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (u UsersHandler) GetUserStatus(w http.ResponseWriter, req *http.Request) {
+	logger := u.logger.Session("get-user-status")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	status, err := u.d.GetSyncStatus(logger, username)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-get-sync-status", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (u UsersHandler) GetUserEncounters(w http.ResponseWriter, req *http.Request) {
+	logger := u.logger.Session("get-user-encounters")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	encounters, err := u.d.Encounters(logger, username)
+	if err != nil {
+		logger.Error("failed-to-get-encounters", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(encounters)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+type SetChatUserRequest struct {
+	ChatUserID string `json:"chat_user_id"`
+}
+
+// SetChatUserID links the user to a chat account so they can use the chat
+// commands. An empty ID unlinks them.
+func (u UsersHandler) SetChatUserID(w http.ResponseWriter, req *http.Request) {
+	logger := u.logger.Session("set-chat-user-id")
+
+	username := req.FormValue(":username")
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	request := &SetChatUserRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = u.d.SetChatUserID(logger, username, request.ChatUserID)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-set-chat-user-id", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const maxDeliveries = 100
+
+type WebhooksHandler struct {
+	logger lager.Logger
+	d      *db.DB
+}
+
+func NewWebhooksHandler(logger lager.Logger, d *db.DB) WebhooksHandler {
+	return WebhooksHandler{logger, d}
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (r CreateWebhookRequest) Validate() bool {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+
+	if r.Secret == "" {
+		return false
+	}
+
+	for _, eventType := range r.EventTypes {
+		known := false
+		for _, t := range models.GameEventTypes {
+			if eventType == t {
+				known = true
+			}
+		}
+		if !known {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h WebhooksHandler) CreateWebhook(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("create-webhook")
+
+	request := &CreateWebhookRequest{}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Error("failed-to-read-body", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		logger.Error("failed-to-parse-request", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !request.Validate() {
+		// Webhook URLs often embed credentials, so only the host is logged.
+		host := ""
+		if u, err := url.Parse(request.URL); err == nil {
+			host = u.Host
+		}
+		logger.Info("invalid-webhook", lager.Data{"host": host})
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	webhook := models.Webhook{
+		URL:        request.URL,
+		Secret:     request.Secret,
+		EventTypes: request.EventTypes,
+		CreatedAt:  time.Now(),
+	}
+
+	webhook.ID, err = h.d.CreateWebhook(logger, webhook)
+	if err != nil {
+		logger.Error("failed-to-create-webhook", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	webhook.Secret = ""
+	data, err = json.Marshal(&webhook)
+	if err != nil {
+		logger.Error("failed-marshalling-data", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// ListWebhooks never returns the webhooks' secrets.
+func (h WebhooksHandler) ListWebhooks(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("list-webhooks")
+
+	webhooks, err := h.d.Webhooks(logger)
+	if err != nil {
+		logger.Error("failed-to-list-webhooks", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	writeJSON(logger, w, webhooks)
+}
+
+func (h WebhooksHandler) DeleteWebhook(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("delete-webhook")
+
+	id, err := strconv.Atoi(req.FormValue(":id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.d.DeleteWebhook(logger, id)
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-delete-webhook", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h WebhooksHandler) ListWebhookDeliveries(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("list-webhook-deliveries")
+
+	id, err := strconv.Atoi(req.FormValue(":id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.d.WebhookDeliveries(logger, id, maxDeliveries)
+	if err != nil {
+		logger.Error("failed-to-list-webhook-deliveries", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(logger, w, deliveries)
+}
+
+// RetryWebhookDelivery requeues a delivery, usually one that went dead.
+func (h WebhooksHandler) RetryWebhookDelivery(w http.ResponseWriter, req *http.Request) {
+	logger := h.logger.Session("retry-webhook-delivery")
+
+	id, err := strconv.Atoi(req.FormValue(":id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(req.FormValue(":delivery_id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.d.RetryWebhookDelivery(logger, id, deliveryID, time.Now())
+	if err == db.ResourceNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-retry-webhook-delivery", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
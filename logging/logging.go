@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+const Redacted = "*REDACTED*"
+
+var levels = map[string]lager.LogLevel{
+	"debug": lager.DEBUG,
+	"info":  lager.INFO,
+	"error": lager.ERROR,
+	"fatal": lager.FATAL,
+}
+
+func ParseLevel(name string) (lager.LogLevel, error) {
+	level, ok := levels[strings.ToLower(name)]
+	if !ok {
+		return lager.DEBUG, fmt.Errorf("unknown log level %q (debug, info, error or fatal)", name)
+	}
+
+	return level, nil
+}
+
+func LevelName(level lager.LogLevel) string {
+	for name, l := range levels {
+		if l == level {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("%d", level)
+}
+
+// sensitiveKeys are matched against log data keys case-insensitively and as
+// substrings, so "tracker_api_token" and "X-TrackerToken" are both caught.
+var sensitiveKeys = []string{"token", "secret", "password", "authorization", "signature"}
+
+type redactingSink struct {
+	sink lager.Sink
+}
+
+// NewRedactingSink replaces the values of sensitive keys anywhere in a log
+// line's data before passing it on, so no call site can leak a credential.
+func NewRedactingSink(sink lager.Sink) lager.Sink {
+	return &redactingSink{sink}
+}
+
+func (r *redactingSink) Log(level lager.LogLevel, payload []byte) {
+	r.sink.Log(level, redactPayload(payload))
+}
+
+func redactPayload(payload []byte) []byte {
+	line := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	err := decoder.Decode(&line)
+	if err != nil {
+		return payload
+	}
+
+	data, ok := line["data"]
+	if !ok {
+		return payload
+	}
+	line["data"] = redact(data)
+
+	redacted, err := json.Marshal(line)
+	if err != nil {
+		return payload
+	}
+
+	return redacted
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redact(inner)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redact(inner)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactURLError replaces the request URL that an HTTP client's *url.Error
+// repeats in its message with just the host, for requests to URLs that are
+// themselves credentials, such as Slack and webhook URLs. Other errors are
+// returned as they are.
+func RedactURLError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+
+	host := ""
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr == nil {
+		host = u.Host
+	}
+
+	return fmt.Errorf("%s %s: %s", urlErr.Op, host, urlErr.Err)
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gotta_track_em_all"
+
+var (
+	CatchesAwarded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "catches_awarded_total",
+		Help:      "Pokemon awarded to users, by rarity tier and encounter pool.",
+	}, []string{"tier", "pool"})
+
+	WatcherCycleDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "watcher",
+		Name:      "cycle_duration_seconds",
+		Help:      "Time taken to sync every due user with Tracker.",
+		Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300},
+	})
+
+	SyncFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "watcher",
+		Name:      "sync_failures_total",
+		Help:      "Failed Tracker syncs, by user and reason.",
+	}, []string{"username", "reason"})
+
+	TrackerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tracker",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of Tracker API requests, by operation and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "status"})
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "API requests served, by route and status code.",
+	}, []string{"route", "status"})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "deliveries_total",
+		Help:      "Webhook delivery attempts, by outcome.",
+	}, []string{"outcome"})
+
+	DBTransactionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_duration_seconds",
+		Help:      "Latency of database transactions, by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		CatchesAwarded,
+		WatcherCycleDuration,
+		SyncFailures,
+		TrackerRequestDuration,
+		HTTPRequests,
+		WebhookDeliveries,
+		DBTransactionDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// InstrumentRoute counts the requests served by handler under the rata
+// route name, which unlike the path does not vary with its parameters.
+func InstrumentRoute(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, req)
+		HTTPRequests.WithLabelValues(route, strconv.Itoa(recorder.status)).Inc()
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Event struct {
+	ID         int
+	Name       string
+	Team       string
+	StartsAt   time.Time
+	EndsAt     time.Time
+	Species    []int
+	Tiers      []string
+	Multiplier float64
+}
+
+func (e Event) ActiveAt(t time.Time) bool {
+	return !t.Before(e.StartsAt) && t.Before(e.EndsAt)
+}
+
+// AppliesTo reports whether the event boosts catches for members of team.
+// Events without a team apply to everyone.
+func (e Event) AppliesTo(team string) bool {
+	return e.Team == "" || e.Team == team
+}
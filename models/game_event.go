@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+const (
+	GameEventCatch       = "catch"
+	GameEventAchievement = "achievement"
+	GameEventTrade       = "trade"
+)
+
+var GameEventTypes = []string{
+	GameEventCatch,
+	GameEventAchievement,
+	GameEventTrade,
+}
+
+// GameEvent is something that happened to a player, published to outside
+// systems as it happens. Only the field matching Type is set.
+type GameEvent struct {
+	Type        string
+	Username    string
+	Team        string
+	OccurredAt  time.Time
+	Catch       *Catch
+	Achievement *Achievement
+	Trade       *Trade
+}
+
+type Catch struct {
+	EncounterID  int
+	FeedSequence int
+	Pokemon      Pokemon
+	Story        *Story
+}
+
+type Story struct {
+	ID   int
+	Name string
+	URL  string
+}
+
+type Achievement struct {
+	Name       string
+	Streak     int
+	BonusRolls int
+}
@@ -0,0 +1,9 @@
+package models
+
+// Project is a Tracker project the user belongs to, flagged if its
+// acceptances currently earn the user catches.
+type Project struct {
+	ID      int
+	Name    string
+	Allowed bool
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Attribution modes decide who is credited for an accepted story: whoever
+// received the acceptance notification, or the story's owners as read from
+// the team's project activity.
+const (
+	AttributionRecipient = "recipient"
+	AttributionOwners    = "owners"
+)
+
+type Team struct {
+	Name                string
+	TrackerProjectIDs   []int
+	Pool                string
+	Attribution         string
+	ActivityProcessedAt time.Time
+	Announcements       Announcements
+	Members             []string
+}
+
+// Announcements posts the team's catches to a Slack incoming webhook. Only
+// catches of MinTier or rarer are posted.
+type Announcements struct {
+	SlackWebhookURL string
+	MinTier         string
+}
+
+type TeamPokemon struct {
+	Index    int
+	Name     string
+	Tier     string
+	Count    int
+	CaughtBy []string
+}
+
+type LeaderboardEntry struct {
+	Username string
+	Caught   int
+	Unique   int
+	Streak   Streak
+}
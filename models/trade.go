@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	TradePending   = "pending"
+	TradeCompleted = "completed"
+)
+
+// Trade swaps one of the proposer's Pokemon for one of the recipient's. It
+// stays pending until the recipient accepts it.
+type Trade struct {
+	ID          int
+	Proposer    string
+	Recipient   string
+	Offered     Pokemon
+	Requested   Pokemon
+	Status      string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+}
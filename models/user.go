@@ -3,14 +3,64 @@ package models
 import "time"
 
 type User struct {
-	Username        string
-	TrackerAPIToken string
-	LastProcessedAt time.Time
-	Pokemon         []string
+	Username          string
+	TrackerAPIToken   string
+	LastProcessedAt   time.Time
+	Pokemon           []Pokemon
+	Streak            Streak
+	Team              string
+	TrackerProjectIDs []int
+	TrackerPersonID   int
+	ChatUserID        string
 }
 
+type Pokemon struct {
+	Index int
+	Name  string
+	Tier  string
+	Pool  string
+}
+
+// DefaultSpriteURL serves a Pokemon's sprite, by national dex number, from
+// the PokeAPI sprite repository.
+const DefaultSpriteURL = "https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/%d.png"
+
 type PokemonEntry struct {
 	Index  int
 	Name   string
 	Weight float64
+	Tier   string
+}
+
+type SyncStatus struct {
+	Username            string
+	LastSyncedAt        time.Time
+	LastError           string
+	ConsecutiveFailures int
+}
+
+type Encounter struct {
+	ID           int
+	FeedSequence int
+	Username     string
+	Roll         float64
+	Pool         string
+	PoolVersion  string
+	PokemonIndex int
+	PokemonName  string
+	CreatedAt    time.Time
+}
+
+// Award is what one pass credits a user with.
+type Award struct {
+	Username   string
+	Caught     []Pokemon
+	Encounters []Encounter
+	Streak     Streak
+}
+
+type Streak struct {
+	Current       int
+	Longest       int
+	LastActiveDay string
 }
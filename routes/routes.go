@@ -7,6 +7,54 @@ const (
 	GetUser    = "GetUser"
 	UpdateUser = "UpdateUser"
 	DeleteUser = "DeleteUser"
+
+	GetUserStatus = "GetUserStatus"
+	SyncUser      = "SyncUser"
+
+	GetUserEncounters = "GetUserEncounters"
+
+	GetUserProjects = "GetUserProjects"
+	SetUserProjects = "SetUserProjects"
+
+	Sync = "Sync"
+
+	CreateEvent = "CreateEvent"
+	ListEvents  = "ListEvents"
+	DeleteEvent = "DeleteEvent"
+
+	CreateTeam         = "CreateTeam"
+	ListTeams          = "ListTeams"
+	GetTeam            = "GetTeam"
+	UpdateTeam         = "UpdateTeam"
+	DeleteTeam         = "DeleteTeam"
+	JoinTeam           = "JoinTeam"
+	LeaveTeam          = "LeaveTeam"
+	GetTeamPokedex     = "GetTeamPokedex"
+	GetTeamLeaderboard = "GetTeamLeaderboard"
+
+	SetTeamAnnouncements = "SetTeamAnnouncements"
+
+	SetChatUserID = "SetChatUserID"
+	ChatCommand   = "ChatCommand"
+
+	Feed = "Feed"
+
+	Dashboard     = "Dashboard"
+	DashboardUser = "DashboardUser"
+	DashboardTeam = "DashboardTeam"
+
+	CreateWebhook         = "CreateWebhook"
+	ListWebhooks          = "ListWebhooks"
+	DeleteWebhook         = "DeleteWebhook"
+	ListWebhookDeliveries = "ListWebhookDeliveries"
+	RetryWebhookDelivery  = "RetryWebhookDelivery"
+
+	GetLogLevel = "GetLogLevel"
+	SetLogLevel = "SetLogLevel"
+
+	Metrics = "Metrics"
+	Healthz = "Healthz"
+	Readyz  = "Readyz"
 )
 
 var Routes = rata.Routes{
@@ -14,4 +62,49 @@ var Routes = rata.Routes{
 	{Path: "/v1/users/:username", Method: "GET", Name: GetUser},
 	{Path: "/v1/users/:username", Method: "PUT", Name: UpdateUser},
 	{Path: "/v1/users/:username", Method: "DELETE", Name: DeleteUser},
+
+	{Path: "/v1/users/:username/status", Method: "GET", Name: GetUserStatus},
+	{Path: "/v1/users/:username/sync", Method: "POST", Name: SyncUser},
+	{Path: "/v1/users/:username/encounters", Method: "GET", Name: GetUserEncounters},
+	{Path: "/v1/users/:username/projects", Method: "GET", Name: GetUserProjects},
+	{Path: "/v1/users/:username/projects", Method: "PUT", Name: SetUserProjects},
+
+	{Path: "/v1/sync", Method: "POST", Name: Sync},
+
+	{Path: "/v1/events", Method: "POST", Name: CreateEvent},
+	{Path: "/v1/events", Method: "GET", Name: ListEvents},
+	{Path: "/v1/events/:id", Method: "DELETE", Name: DeleteEvent},
+
+	{Path: "/v1/teams", Method: "POST", Name: CreateTeam},
+	{Path: "/v1/teams", Method: "GET", Name: ListTeams},
+	{Path: "/v1/teams/:team", Method: "GET", Name: GetTeam},
+	{Path: "/v1/teams/:team", Method: "PUT", Name: UpdateTeam},
+	{Path: "/v1/teams/:team", Method: "DELETE", Name: DeleteTeam},
+	{Path: "/v1/teams/:team/members/:username", Method: "PUT", Name: JoinTeam},
+	{Path: "/v1/teams/:team/members/:username", Method: "DELETE", Name: LeaveTeam},
+	{Path: "/v1/teams/:team/pokedex", Method: "GET", Name: GetTeamPokedex},
+	{Path: "/v1/teams/:team/leaderboard", Method: "GET", Name: GetTeamLeaderboard},
+	{Path: "/v1/teams/:team/announcements", Method: "PUT", Name: SetTeamAnnouncements},
+
+	{Path: "/v1/users/:username/chat", Method: "PUT", Name: SetChatUserID},
+	{Path: "/v1/chat/command", Method: "POST", Name: ChatCommand},
+
+	{Path: "/v1/feed", Method: "GET", Name: Feed},
+
+	{Path: "/dashboard", Method: "GET", Name: Dashboard},
+	{Path: "/dashboard/users/:username", Method: "GET", Name: DashboardUser},
+	{Path: "/dashboard/teams/:team", Method: "GET", Name: DashboardTeam},
+
+	{Path: "/v1/webhooks", Method: "POST", Name: CreateWebhook},
+	{Path: "/v1/webhooks", Method: "GET", Name: ListWebhooks},
+	{Path: "/v1/webhooks/:id", Method: "DELETE", Name: DeleteWebhook},
+	{Path: "/v1/webhooks/:id/deliveries", Method: "GET", Name: ListWebhookDeliveries},
+	{Path: "/v1/webhooks/:id/deliveries/:delivery_id/retry", Method: "POST", Name: RetryWebhookDelivery},
+
+	{Path: "/v1/admin/log-level", Method: "GET", Name: GetLogLevel},
+	{Path: "/v1/admin/log-level", Method: "PUT", Name: SetLogLevel},
+
+	{Path: "/metrics", Method: "GET", Name: Metrics},
+	{Path: "/healthz", Method: "GET", Name: Healthz},
+	{Path: "/readyz", Method: "GET", Name: Readyz},
 }
@@ -0,0 +1,104 @@
+package streak
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const DayFormat = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Calendar decides which days count towards a streak. Days are evaluated in
+// the calendar's location so a late-evening acceptance lands on the right
+// day for the team.
+type Calendar struct {
+	location    *time.Location
+	workingDays map[time.Weekday]bool
+	holidays    map[string]bool
+}
+
+func DefaultCalendar() Calendar {
+	calendar, _ := NewCalendar("Mon,Tue,Wed,Thu,Fri", "", "UTC")
+	return calendar
+}
+
+// NewCalendar takes a comma separated list of weekday abbreviations, a comma
+// separated list of YYYY-MM-DD holidays and a time zone name.
+func NewCalendar(workingDays, holidays, timezone string) (Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	calendar := Calendar{
+		location:    location,
+		workingDays: map[time.Weekday]bool{},
+		holidays:    map[string]bool{},
+	}
+
+	for _, day := range splitList(workingDays) {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return Calendar{}, fmt.Errorf("unknown working day %q", day)
+		}
+		calendar.workingDays[weekday] = true
+	}
+
+	if len(calendar.workingDays) == 0 {
+		return Calendar{}, fmt.Errorf("at least one working day is required")
+	}
+
+	for _, day := range splitList(holidays) {
+		_, err := time.Parse(DayFormat, day)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("invalid holiday %q", day)
+		}
+		calendar.holidays[day] = true
+	}
+
+	return calendar, nil
+}
+
+func (c Calendar) IsZero() bool {
+	return c.location == nil
+}
+
+func (c Calendar) Day(t time.Time) string {
+	return t.In(c.location).Format(DayFormat)
+}
+
+func (c Calendar) IsWorkingDay(t time.Time) bool {
+	t = t.In(c.location)
+	return c.workingDays[t.Weekday()] && !c.holidays[t.Format(DayFormat)]
+}
+
+func (c Calendar) PreviousWorkingDay(t time.Time) string {
+	t = t.In(c.location)
+	for {
+		t = t.AddDate(0, 0, -1)
+		if c.IsWorkingDay(t) {
+			return t.Format(DayFormat)
+		}
+	}
+}
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
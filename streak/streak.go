@@ -0,0 +1,111 @@
+package streak
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+// Milestone grants BonusRolls when a streak reaches Days working days.
+type Milestone struct {
+	Days       int
+	BonusRolls int
+}
+
+var DefaultMilestones = []Milestone{
+	{Days: 5, BonusRolls: 1},
+	{Days: 10, BonusRolls: 2},
+	{Days: 20, BonusRolls: 3},
+}
+
+// ParseMilestones reads a comma separated list of days:bonusRolls pairs,
+// e.g. "5:1,10:2".
+func ParseMilestones(list string) ([]Milestone, error) {
+	milestones := []Milestone{}
+
+	for _, item := range splitList(list) {
+		parts := strings.Split(item, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid milestone %q", item)
+		}
+
+		days, err := strconv.Atoi(parts[0])
+		if err != nil || days < 1 {
+			return nil, fmt.Errorf("invalid milestone days %q", item)
+		}
+
+		rolls, err := strconv.Atoi(parts[1])
+		if err != nil || rolls < 0 {
+			return nil, fmt.Errorf("invalid milestone bonus rolls %q", item)
+		}
+
+		milestones = append(milestones, Milestone{Days: days, BonusRolls: rolls})
+	}
+
+	sort.Slice(milestones, func(i, j int) bool { return milestones[i].Days < milestones[j].Days })
+	return milestones, nil
+}
+
+// Advance records activity at t. Activity on a non-working day neither
+// extends nor breaks a streak; activity on a working day extends it when the
+// previous working day was also active and restarts it otherwise. Activity
+// on or before the last active day, such as a backfilled entry read out of
+// order, is already counted and leaves the streak alone.
+func Advance(calendar Calendar, streak models.Streak, t time.Time) models.Streak {
+	if !calendar.IsWorkingDay(t) {
+		return streak
+	}
+
+	day := calendar.Day(t)
+	if streak.LastActiveDay != "" && day <= streak.LastActiveDay {
+		return streak
+	}
+
+	if streak.LastActiveDay == calendar.PreviousWorkingDay(t) {
+		streak.Current++
+	} else {
+		streak.Current = 1
+	}
+
+	streak.LastActiveDay = day
+	if streak.Current > streak.Longest {
+		streak.Longest = streak.Current
+	}
+
+	return streak
+}
+
+// Current returns the streak as of t, reporting it as broken when a working
+// day has passed without activity.
+func Current(calendar Calendar, streak models.Streak, t time.Time) models.Streak {
+	if streak.LastActiveDay == "" {
+		return streak
+	}
+
+	if streak.LastActiveDay >= calendar.PreviousWorkingDay(t) {
+		return streak
+	}
+
+	streak.Current = 0
+	return streak
+}
+
+// BonusRolls returns the rolls earned by moving from before to after.
+func BonusRolls(milestones []Milestone, before, after models.Streak) int {
+	if after.Current == before.Current {
+		return 0
+	}
+
+	rolls := 0
+	for _, milestone := range milestones {
+		if milestone.Days == after.Current {
+			rolls += milestone.BonusRolls
+		}
+	}
+
+	return rolls
+}
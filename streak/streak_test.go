@@ -0,0 +1,35 @@
+package streak
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+func TestAdvanceIgnoresEarlierActivity(t *testing.T) {
+	calendar := DefaultCalendar()
+	monday := time.Date(2016, 6, 20, 10, 0, 0, 0, time.UTC)
+
+	streak := models.Streak{}
+	for day := 0; day < 3; day++ {
+		streak = Advance(calendar, streak, monday.AddDate(0, 0, day))
+	}
+
+	want := models.Streak{Current: 3, Longest: 3, LastActiveDay: "2016-06-22"}
+	if streak != want {
+		t.Fatalf("after Monday to Wednesday got %+v, want %+v", streak, want)
+	}
+
+	for _, earlier := range []time.Time{monday, monday.AddDate(0, 0, 1), monday.AddDate(0, 0, -7)} {
+		got := Advance(calendar, streak, earlier)
+		if got != want {
+			t.Errorf("activity on %s changed the streak to %+v, want %+v", calendar.Day(earlier), got, want)
+		}
+	}
+
+	got := Advance(calendar, streak, monday.AddDate(0, 0, 3))
+	if got.Current != 4 || got.LastActiveDay != "2016-06-23" {
+		t.Errorf("Thursday after the earlier activity got %+v, want a 4 day streak", got)
+	}
+}
@@ -0,0 +1,129 @@
+package tracker
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// maxActivity is the most activity Tracker returns in one page.
+const maxActivity = 500
+
+type Person struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type Activity struct {
+	GUID       string    `json:"guid"`
+	Kind       string    `json:"kind"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Project    Project   `json:"project"`
+	Changes    []Change  `json:"changes"`
+}
+
+// activityPage is the envelope Tracker wraps a page of activity in when
+// asked, so the total is known without reading headers.
+type activityPage struct {
+	Data       []Activity `json:"data"`
+	Pagination struct {
+		Total int `json:"total"`
+	} `json:"pagination"`
+}
+
+type Change struct {
+	Kind      string       `json:"kind"`
+	ID        int          `json:"id"`
+	NewValues ChangeValues `json:"new_values"`
+}
+
+type ChangeValues struct {
+	CurrentState string `json:"current_state"`
+}
+
+type Story struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	OwnerIDs []int  `json:"owner_ids"`
+}
+
+// StoryURL links to a story in the Tracker web UI for when the API did not
+// include one.
+func StoryURL(id int) string {
+	return fmt.Sprintf("https://www.pivotaltracker.com/story/show/%d", id)
+}
+
+// AcceptedStoryIDs returns the stories this activity moved to accepted.
+func (a Activity) AcceptedStoryIDs() []int {
+	ids := []int{}
+	for _, change := range a.Changes {
+		if change.Kind == "story" && change.NewValues.CurrentState == "accepted" {
+			ids = append(ids, change.ID)
+		}
+	}
+	return ids
+}
+
+// Me returns the person the token belongs to.
+func (c *Client) Me(logger lager.Logger, token string) (Person, error) {
+	person := Person{}
+	err := c.get(logger, "me", token, "/me?fields=id,username", &person)
+	if err != nil {
+		return Person{}, err
+	}
+
+	return person, nil
+}
+
+// ProjectActivity takes the cutoff in milliseconds, which Tracker accepts in
+// place of a timestamp, so a cursor saved mid-second is not read twice. It
+// pages through the envelope until Tracker has returned everything; activity
+// comes newest first, so anything recorded mid-read pushes older items to a
+// later page and they are dropped by GUID when seen again.
+func (c *Client) ProjectActivity(logger lager.Logger, token string, projectID int, occurredAfter time.Time) ([]Activity, error) {
+	query := url.Values{}
+	query.Set("occurred_after", strconv.FormatInt(occurredAfter.UnixNano()/int64(time.Millisecond), 10))
+	query.Set("limit", fmt.Sprintf("%d", maxActivity))
+	query.Set("envelope", "true")
+
+	activity := []Activity{}
+	seen := map[string]bool{}
+	for offset := 0; ; {
+		query.Set("offset", strconv.Itoa(offset))
+
+		page := activityPage{}
+		err := c.get(logger, "project-activity", token, fmt.Sprintf("/projects/%d/activity?%s", projectID, query.Encode()), &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Data {
+			if item.GUID != "" && seen[item.GUID] {
+				continue
+			}
+			seen[item.GUID] = true
+			activity = append(activity, item)
+		}
+
+		offset += len(page.Data)
+		if len(page.Data) == 0 || offset >= page.Pagination.Total {
+			break
+		}
+	}
+
+	return activity, nil
+}
+
+func (c *Client) Story(logger lager.Logger, token string, projectID, storyID int) (Story, error) {
+	story := Story{}
+	err := c.get(logger, "story", token, fmt.Sprintf("/projects/%d/stories/%d?fields=id,name,url,owner_ids", projectID, storyID), &story)
+	if err != nil {
+		return Story{}, err
+	}
+
+	return story, nil
+}
@@ -0,0 +1,184 @@
+package tracker
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/metrics"
+	"github.com/pivotal-golang/lager"
+)
+
+const DefaultURL = "https://www.pivotaltracker.com/services/v5"
+
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = time.Second
+)
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	maxRetries int
+	retryDelay time.Duration
+}
+
+func NewClient(httpClient *http.Client, baseURL string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		maxRetries: DefaultMaxRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+}
+
+type Notification struct {
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+	Project   Project   `json:"project"`
+	Story     Story     `json:"story"`
+}
+
+type Project struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ByCreatedAt []Notification
+
+func (n ByCreatedAt) Len() int           { return len(n) }
+func (n ByCreatedAt) Less(i, j int) bool { return n[i].CreatedAt.Before(n[j].CreatedAt) }
+func (n ByCreatedAt) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+func (c *Client) Notifications(logger lager.Logger, token string, createdAfter time.Time) ([]Notification, error) {
+	query := url.Values{}
+	query.Set("created_after", createdAfter.Format(time.RFC3339))
+
+	notifications := []Notification{}
+	err := c.get(logger, "notifications", token, "/my/notifications?"+query.Encode(), &notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// Projects lists the projects the token's owner is a member of.
+func (c *Client) Projects(logger lager.Logger, token string) ([]Project, error) {
+	projects := []Project{}
+	err := c.get(logger, "projects", token, "/projects?fields=id,name", &projects)
+	if err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// get retries server errors with jittered exponential backoff. Rate limiting
+// is surfaced to the caller so it can honor Retry-After without tying up a
+// worker. The operation names the request in metrics.
+func (c *Client) get(logger lager.Logger, operation, token, path string, result interface{}) error {
+	var err error
+
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := c.backoff(attempt)
+			logger.Info("retrying-request", lager.Data{"attempt": attempt, "delay": delay.String()})
+			time.Sleep(delay)
+		}
+
+		err = c.doGet(logger, operation, token, path, result)
+		if _, ok := err.(ServerError); !ok {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (c *Client) doGet(logger lager.Logger, operation, token, path string, result interface{}) error {
+	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+	if err != nil {
+		logger.Error("failed-to-create-request", err)
+		return err
+	}
+
+	req.Header.Add("X-TrackerToken", token)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		metrics.TrackerRequestDuration.WithLabelValues(operation, "error").Observe(metrics.Since(start))
+		logger.Error("failed-to-make-request", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	metrics.TrackerRequestDuration.WithLabelValues(operation, strconv.Itoa(resp.StatusCode)).Observe(metrics.Since(start))
+
+	err = checkStatus(resp)
+	if err != nil {
+		logger.Error("failed-request", err, lager.Data{"status-code": resp.StatusCode})
+		return err
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		logger.Error("failed-to-unmarshal-response", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	max := c.retryDelay << uint(attempt)
+	return max/2 + time.Duration(rand.Int63n(int64(max/2)+1))
+}
+
+func checkStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized:
+		return UnauthorizedError{StatusCode: resp.StatusCode}
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusServiceUnavailable:
+		return RateLimitedError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	case resp.StatusCode >= 500:
+		return ServerError{StatusCode: resp.StatusCode}
+	default:
+		return UnexpectedStatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+
+	delay := at.Sub(time.Now())
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
@@ -0,0 +1,39 @@
+package tracker
+
+import (
+	"fmt"
+	"time"
+)
+
+type UnauthorizedError struct {
+	StatusCode int
+}
+
+func (e UnauthorizedError) Error() string {
+	return fmt.Sprintf("tracker-unauthorized: status %d", e.StatusCode)
+}
+
+type RateLimitedError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e RateLimitedError) Error() string {
+	return fmt.Sprintf("tracker-rate-limited: status %d, retry after %s", e.StatusCode, e.RetryAfter)
+}
+
+type ServerError struct {
+	StatusCode int
+}
+
+func (e ServerError) Error() string {
+	return fmt.Sprintf("tracker-server-error: status %d", e.StatusCode)
+}
+
+type UnexpectedStatusError struct {
+	StatusCode int
+}
+
+func (e UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("tracker-unexpected-status: status %d", e.StatusCode)
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+var ErrNoCertificates = errors.New("no-certificates-in-ca-bundle")
+
+// Configure trusts the certificates in caCertPath in addition to the system
+// roots and routes requests through proxyURL. Empty values leave certificate
+// verification at its defaults and fall back to the standard proxy
+// environment variables.
+func Configure(tr *http.Transport, caCertPath, proxyURL string) error {
+	if caCertPath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		bundle, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return err
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return ErrNoCertificates
+		}
+
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+		tr.TLSClientConfig.RootCAs = pool
+	}
+
+	tr.Proxy = http.ProxyFromEnvironment
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return err
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package watcher
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
+	"github.com/pivotal-golang/lager"
+)
+
+// creditOwners awards catches to the owners of every story accepted in the
+// team's projects since the team was last processed. A team's first pass
+// only starts the cursor so enabling the mode does not pay out history, and
+// activity is skipped while no member has been matched to a Tracker person.
+// Otherwise every owner's catches are recorded together with the cursor,
+// moved to the newest activity read, so a failed pass leaves nothing behind
+// to be paid twice when it is retried.
+func (w *Watcher) creditOwners(logger lager.Logger, team *models.Team, members []*models.User, now time.Time) error {
+	logger = logger.Session("credit-owners", lager.Data{"team": team.Name})
+
+	owners := map[int]*models.User{}
+	for _, member := range members {
+		if member.TrackerPersonID != 0 {
+			owners[member.TrackerPersonID] = member
+		}
+	}
+
+	if team.ActivityProcessedAt.IsZero() || len(owners) == 0 {
+		err := w.d.SetTeamActivityProcessedAt(logger, team.Name, now)
+		if err != nil {
+			logger.Error("failed-to-advance-activity-cursor", err)
+			return err
+		}
+		return nil
+	}
+
+	processedAt := team.ActivityProcessedAt
+	acceptances := map[string][]acceptance{}
+	for _, projectID := range team.TrackerProjectIDs {
+		latest, err := w.ownerAcceptances(logger, projectID, team.ActivityProcessedAt, members, owners, acceptances)
+		if err != nil {
+			return err
+		}
+		if latest.After(processedAt) {
+			processedAt = latest
+		}
+	}
+
+	if !processedAt.After(team.ActivityProcessedAt) {
+		return nil
+	}
+
+	awarded := []*models.User{}
+	rolled := []rolledAward{}
+	awards := []models.Award{}
+	for _, member := range members {
+		accepted := acceptances[member.Username]
+		if len(accepted) == 0 {
+			continue
+		}
+		sort.Sort(byTime(accepted))
+
+		award, err := w.rollAward(logger.Session("award", lager.Data{"username": member.Username}), member, team, accepted, now)
+		if err != nil {
+			logger.Error("failed-to-award-owner", err, lager.Data{"username": member.Username})
+			return err
+		}
+
+		awarded = append(awarded, member)
+		rolled = append(rolled, award)
+		awards = append(awards, award.Award)
+	}
+
+	err := w.d.AwardOwners(logger, team.Name, awards, processedAt, now)
+	if err != nil {
+		logger.Error("failed-to-award-owners", err)
+		return err
+	}
+
+	for i, member := range awarded {
+		w.publish(logger.Session("award", lager.Data{"username": member.Username}), member, rolled[i], now)
+	}
+
+	return nil
+}
+
+// ownerAcceptances collects the project's acceptances by owner and returns
+// when the newest activity read occurred.
+func (w *Watcher) ownerAcceptances(logger lager.Logger, projectID int, since time.Time, members []*models.User, owners map[int]*models.User, acceptances map[string][]acceptance) (time.Time, error) {
+	activity, token, err := w.projectActivity(logger, projectID, since, members)
+	if err != nil {
+		logger.Error("failed-to-fetch-project-activity", err, lager.Data{"project-id": projectID})
+		return time.Time{}, err
+	}
+
+	latest := time.Time{}
+	for _, item := range activity {
+		if item.OccurredAt.After(latest) {
+			latest = item.OccurredAt
+		}
+
+		for _, storyID := range item.AcceptedStoryIDs() {
+			story, err := w.trackerClient.Story(logger, token, projectID, storyID)
+			if err != nil {
+				logger.Error("failed-to-fetch-story", err, lager.Data{"story-id": storyID})
+				return time.Time{}, err
+			}
+
+			accepted := acceptance{at: item.OccurredAt, story: storyFor(story)}
+			for _, ownerID := range story.OwnerIDs {
+				owner, ok := owners[ownerID]
+				if !ok {
+					continue
+				}
+				acceptances[owner.Username] = append(acceptances[owner.Username], accepted)
+			}
+		}
+	}
+
+	return latest, nil
+}
+
+// projectActivity reads the project with the first member token Tracker
+// accepts, returning that token for follow-up requests.
+func (w *Watcher) projectActivity(logger lager.Logger, projectID int, since time.Time, members []*models.User) ([]tracker.Activity, string, error) {
+	var err error
+	for _, member := range members {
+		var activity []tracker.Activity
+		activity, err = w.trackerClient.ProjectActivity(logger, member.TrackerAPIToken, projectID, since)
+		if err == nil {
+			return activity, member.TrackerAPIToken, nil
+		}
+
+		switch err.(type) {
+		case tracker.UnauthorizedError, tracker.UnexpectedStatusError:
+			continue
+		default:
+			return nil, "", err
+		}
+	}
+
+	return nil, "", err
+}
+
+type byTime []acceptance
+
+func (t byTime) Len() int           { return len(t) }
+func (t byTime) Less(i, j int) bool { return t[i].at.Before(t[j].at) }
+func (t byTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
@@ -0,0 +1,347 @@
+package watcher
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/catalog"
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
+	"github.com/pivotal-golang/lager"
+)
+
+// ledger stands in for the tables crediting owners touches. It is served
+// through database/sql so the real db code runs against it, and writes made
+// in a transaction only land when it commits.
+type ledger struct {
+	mu        sync.Mutex
+	pokemon   map[string]string
+	cursors   map[string]int64
+	failUsers map[string]int
+	nextID    int64
+}
+
+type ledgerWrites struct {
+	pokemon map[string]string
+	cursors map[string]int64
+}
+
+// caught counts the catches committed for username.
+func (l *ledger) caught(username string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pokemon := []models.Pokemon{}
+	json.Unmarshal([]byte(l.pokemon[username]), &pokemon)
+	return len(pokemon)
+}
+
+func (l *ledger) cursor(team string) (int64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cursor, ok := l.cursors[team]
+	return cursor, ok
+}
+
+var (
+	ledgersLock sync.Mutex
+	ledgers     = map[string]*ledger{}
+)
+
+func init() {
+	sql.Register("ledger", ledgerDriver{})
+}
+
+func openLedger(t *testing.T, l *ledger) *db.DB {
+	ledgersLock.Lock()
+	ledgers[t.Name()] = l
+	ledgersLock.Unlock()
+
+	sqlConn, err := sql.Open("ledger", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.NewDB(sqlConn)
+}
+
+type ledgerDriver struct{}
+
+func (ledgerDriver) Open(name string) (driver.Conn, error) {
+	ledgersLock.Lock()
+	defer ledgersLock.Unlock()
+
+	l, ok := ledgers[name]
+	if !ok {
+		return nil, fmt.Errorf("no ledger %q", name)
+	}
+	return &ledgerConn{l: l}, nil
+}
+
+type ledgerConn struct {
+	l  *ledger
+	tx *ledgerWrites
+}
+
+func (c *ledgerConn) Prepare(query string) (driver.Stmt, error) {
+	return ledgerStmt{c, query}, nil
+}
+
+func (c *ledgerConn) Close() error { return nil }
+
+func (c *ledgerConn) Begin() (driver.Tx, error) {
+	c.tx = &ledgerWrites{pokemon: map[string]string{}, cursors: map[string]int64{}}
+	return ledgerTx{c}, nil
+}
+
+type ledgerTx struct{ c *ledgerConn }
+
+func (tx ledgerTx) Commit() error {
+	l := tx.c.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for username, pokemon := range tx.c.tx.pokemon {
+		l.pokemon[username] = pokemon
+	}
+	for team, cursor := range tx.c.tx.cursors {
+		l.cursors[team] = cursor
+	}
+	tx.c.tx = nil
+	return nil
+}
+
+func (tx ledgerTx) Rollback() error {
+	tx.c.tx = nil
+	return nil
+}
+
+type ledgerStmt struct {
+	c     *ledgerConn
+	query string
+}
+
+func (s ledgerStmt) Close() error  { return nil }
+func (s ledgerStmt) NumInput() int { return -1 }
+
+func (s ledgerStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, err := s.Query(args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s ledgerStmt) Query(args []driver.Value) (driver.Rows, error) {
+	l := s.c.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	writes := &ledgerWrites{pokemon: l.pokemon, cursors: l.cursors}
+	if s.c.tx != nil {
+		writes = s.c.tx
+	}
+
+	switch {
+	case strings.Contains(s.query, "FROM events"):
+		return &ledgerRows{}, nil
+
+	case strings.Contains(s.query, "SELECT pokemon FROM users"):
+		username := args[0].(string)
+		if l.failUsers[username] > 0 {
+			l.failUsers[username]--
+			return nil, errors.New("connection reset by peer")
+		}
+
+		pokemon, ok := writes.pokemon[username]
+		if !ok {
+			pokemon, ok = l.pokemon[username]
+		}
+		if !ok {
+			return &ledgerRows{}, nil
+		}
+		return &ledgerRows{[][]driver.Value{{pokemon}}}, nil
+
+	case strings.Contains(s.query, "UPDATE users SET pokemon"):
+		writes.pokemon[args[5].(string)] = args[0].(string)
+		return &ledgerRows{}, nil
+
+	case strings.Contains(s.query, "INSERT INTO encounters"):
+		l.nextID++
+		return &ledgerRows{[][]driver.Value{{l.nextID}}}, nil
+
+	case strings.Contains(s.query, "pg_advisory_xact_lock"):
+		return &ledgerRows{}, nil
+
+	case strings.Contains(s.query, "UPDATE encounters SET feed_sequence"):
+		return &ledgerRows{[][]driver.Value{{args[0]}}}, nil
+
+	case strings.Contains(s.query, "UPDATE teams SET activity_processed_at"):
+		writes.cursors[args[1].(string)] = args[0].(int64)
+		return &ledgerRows{}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type ledgerRows struct {
+	values [][]driver.Value
+}
+
+func (r *ledgerRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *ledgerRows) Close() error { return nil }
+
+func (r *ledgerRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type recordingPublisher struct {
+	events []models.GameEvent
+}
+
+func (p *recordingPublisher) Publish(logger lager.Logger, event models.GameEvent) {
+	p.events = append(p.events, event)
+}
+
+// newTrackerServer serves one activity accepting story 10, owned by Tracker
+// people 1 and 2, at acceptedAt in project 1.
+func newTrackerServer(acceptedAt time.Time) *httptest.Server {
+	acceptedAtMillis := acceptedAt.UnixNano() / int64(time.Millisecond)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/projects/1/activity", func(w http.ResponseWriter, req *http.Request) {
+		occurredAfter, _ := strconv.ParseInt(req.FormValue("occurred_after"), 10, 64)
+
+		activity := []tracker.Activity{}
+		if acceptedAtMillis > occurredAfter && req.FormValue("offset") == "0" {
+			activity = append(activity, tracker.Activity{
+				GUID:       "1_100",
+				Kind:       "story_update_activity",
+				OccurredAt: acceptedAt,
+				Changes: []tracker.Change{
+					{Kind: "story", ID: 10, NewValues: tracker.ChangeValues{CurrentState: "accepted"}},
+				},
+			})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":       activity,
+			"pagination": map[string]int{"total": len(activity)},
+		})
+	})
+	mux.HandleFunc("/projects/1/stories/10", func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode(tracker.Story{ID: 10, Name: "Catch them all", OwnerIDs: []int{1, 2}})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestCreditOwnersRetriesFailedPassWithoutPayingTwice(t *testing.T) {
+	cursor := time.Date(2016, 6, 21, 9, 0, 0, 0, time.UTC)
+	acceptedAt := cursor.Add(time.Hour)
+	now := acceptedAt.Add(time.Minute)
+
+	server := newTrackerServer(acceptedAt)
+	defer server.Close()
+
+	l := &ledger{
+		pokemon:   map[string]string{"ash": "[]", "misty": "[]"},
+		cursors:   map[string]int64{},
+		failUsers: map[string]int{"misty": 1},
+	}
+
+	entries, err := catalog.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pools, err := encounter.NewPools(entries, encounter.DefaultTiers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{}
+	logger := lager.NewLogger("test")
+	w := NewWatcher(logger, openLedger(t, l), tracker.NewClient(http.DefaultClient, server.URL), pools, Config{
+		Publisher:    publisher,
+		RandomSource: rand.NewSource(1),
+	})
+
+	team := &models.Team{Name: "kanto", TrackerProjectIDs: []int{1}, ActivityProcessedAt: cursor}
+	members := []*models.User{
+		{Username: "ash", Team: "kanto", TrackerAPIToken: "ash-token", TrackerPersonID: 1},
+		{Username: "misty", Team: "kanto", TrackerAPIToken: "misty-token", TrackerPersonID: 2},
+	}
+
+	err = w.creditOwners(logger, team, members, now)
+	if err == nil {
+		t.Fatal("expected the pass to fail when misty's award cannot be recorded")
+	}
+	if got := l.caught("ash"); got != 0 {
+		t.Errorf("after the failed pass ash has %d catches, want 0", got)
+	}
+	if _, ok := l.cursor("kanto"); ok {
+		t.Error("the failed pass advanced the activity cursor")
+	}
+	if len(publisher.events) != 0 {
+		t.Errorf("the failed pass published %d events", len(publisher.events))
+	}
+
+	// Retry, then run again from the cursor the retry saved, as the next
+	// cycle would after reloading the team.
+	for pass := 0; pass < 2; pass++ {
+		err = w.creditOwners(logger, team, members, now)
+		if err != nil {
+			t.Fatalf("pass %d: %s", pass+2, err)
+		}
+
+		saved, ok := l.cursor("kanto")
+		if !ok {
+			t.Fatalf("pass %d did not save the activity cursor", pass+2)
+		}
+		team.ActivityProcessedAt = time.Unix(0, saved)
+	}
+
+	for _, username := range []string{"ash", "misty"} {
+		if got := l.caught(username); got != 1 {
+			t.Errorf("%s has %d catches, want 1", username, got)
+		}
+	}
+	if !team.ActivityProcessedAt.Equal(acceptedAt) {
+		t.Errorf("cursor is %s, want the accepted activity at %s", team.ActivityProcessedAt, acceptedAt)
+	}
+
+	catches := 0
+	for _, event := range publisher.events {
+		if event.Type == models.GameEventCatch {
+			catches++
+		}
+	}
+	if catches != 2 {
+		t.Errorf("published %d catches, want 2", catches)
+	}
+}
@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+const MaxBackoff = 30 * time.Minute
+
+type scheduleEntry struct {
+	nextPollAt    time.Time
+	failures      int
+	deferred      bool
+	rejectedToken string
+}
+
+type schedule struct {
+	lock    sync.Mutex
+	entries map[string]*scheduleEntry
+}
+
+func newSchedule() *schedule {
+	return &schedule{entries: map[string]*scheduleEntry{}}
+}
+
+// Due reports whether the user should be polled. A forced poll skips the
+// wait for the next poll interval but still honours backoff after failures,
+// a Retry-After from Tracker and a rejected token.
+func (s *schedule) Due(user *models.User, now time.Time, force bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.entries[user.Username]
+	if !ok {
+		return true
+	}
+
+	if entry.rejectedToken != "" {
+		return entry.rejectedToken != user.TrackerAPIToken || !now.Before(entry.nextPollAt)
+	}
+
+	if force && entry.failures == 0 && !entry.deferred {
+		return true
+	}
+
+	return !now.Before(entry.nextPollAt)
+}
+
+func (s *schedule) Succeeded(username string, now time.Time, interval time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entries[username] = &scheduleEntry{nextPollAt: now.Add(interval)}
+}
+
+// Failed pushes the user's next poll out exponentially with each consecutive
+// failure, capped at MaxBackoff, and returns the delay that was applied.
+func (s *schedule) Failed(username string, now time.Time, interval time.Duration) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.entries[username]
+	if !ok {
+		entry = &scheduleEntry{}
+		s.entries[username] = entry
+	}
+
+	entry.failures++
+
+	backoff := interval
+	for i := 0; i < entry.failures && backoff < MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxBackoff {
+		backoff = MaxBackoff
+	}
+
+	entry.nextPollAt = now.Add(backoff)
+	return backoff
+}
+
+func (s *schedule) Defer(username string, until time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.entries[username]
+	if !ok {
+		entry = &scheduleEntry{}
+		s.entries[username] = entry
+	}
+
+	entry.nextPollAt = until
+	entry.deferred = true
+}
+
+// Unauthorized flags the user's current token as rejected. The user is polled
+// again as soon as the token changes, or after MaxBackoff otherwise.
+func (s *schedule) Unauthorized(user *models.User, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.entries[user.Username]
+	if !ok {
+		entry = &scheduleEntry{}
+		s.entries[user.Username] = entry
+	}
+
+	entry.failures++
+	entry.rejectedToken = user.TrackerAPIToken
+	entry.nextPollAt = now.Add(MaxBackoff)
+}
+
+// Prune forgets users that are no longer registered.
+func (s *schedule) Prune(users []*models.User) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	registered := map[string]struct{}{}
+	for _, user := range users {
+		registered[user.Username] = struct{}{}
+	}
+
+	for username := range s.entries {
+		if _, ok := registered[username]; !ok {
+			delete(s.entries, username)
+		}
+	}
+}
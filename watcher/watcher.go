@@ -1,144 +1,604 @@
 package watcher
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
-	"net/http"
 	"os"
-	"strconv"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/encounter"
+	"github.com/jfmyers9/gotta-track-em-all/metrics"
 	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/jfmyers9/gotta-track-em-all/streak"
+	"github.com/jfmyers9/gotta-track-em-all/tracker"
 	"github.com/pivotal-golang/lager"
 )
 
+const (
+	DefaultPollInterval = 30 * time.Second
+	DefaultWorkers      = 10
+
+	maxPendingSyncs = 100
+)
+
+var ErrSyncPending = errors.New("too-many-pending-syncs")
+
+// Publisher is told about catches and achievements once they are recorded.
+type Publisher interface {
+	Publish(logger lager.Logger, event models.GameEvent)
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(lager.Logger, models.GameEvent) {}
+
+// Publishers fans each event out to every publisher in turn.
+type Publishers []Publisher
+
+func (p Publishers) Publish(logger lager.Logger, event models.GameEvent) {
+	for _, publisher := range p {
+		publisher.Publish(logger, event)
+	}
+}
+
+type Config struct {
+	Publisher    Publisher
+	Workers      int
+	PollInterval time.Duration
+	RandomSource rand.Source
+	Pool         string
+	Calendar     streak.Calendar
+	Milestones   []streak.Milestone
+}
+
+type syncRequest struct {
+	username string
+	force    bool
+}
+
 type Watcher struct {
-	logger           lager.Logger
-	d                *db.DB
-	httpClient       *http.Client
-	pokemonList      []*models.PokemonEntry
-	maxPokemonNumber float64
+	logger        lager.Logger
+	d             *db.DB
+	trackerClient *tracker.Client
+	pools         *encounter.Pools
+	config        Config
+	schedule      *schedule
+	syncRequests  chan syncRequest
+
+	randLock sync.Mutex
+	random   *rand.Rand
+
+	cycleLock          sync.Mutex
+	lastCycleCompleted time.Time
+}
+
+func NewWatcher(logger lager.Logger, d *db.DB, trackerClient *tracker.Client, pools *encounter.Pools, config Config) *Watcher {
+	if config.Publisher == nil {
+		config.Publisher = nopPublisher{}
+	}
+	if config.Workers < 1 {
+		config.Workers = DefaultWorkers
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.Calendar.IsZero() {
+		config.Calendar = streak.DefaultCalendar()
+	}
+	if config.Milestones == nil {
+		config.Milestones = streak.DefaultMilestones
+	}
+	if config.Pool == "" {
+		config.Pool = encounter.DefaultPool
+	}
+	if config.RandomSource == nil {
+		config.RandomSource = rand.NewSource(time.Now().UnixNano())
+	}
+
+	return &Watcher{
+		logger:        logger,
+		d:             d,
+		trackerClient: trackerClient,
+		pools:         pools,
+		config:        config,
+		schedule:      newSchedule(),
+		syncRequests:  make(chan syncRequest, maxPendingSyncs),
+		random:        rand.New(config.RandomSource),
+	}
+}
+
+// Sync asks the running watcher to poll Tracker immediately rather than at
+// the next poll interval. Users backing off after failures, asked by Tracker
+// to retry later or whose token was rejected are still skipped. An empty
+// username syncs every registered user.
+func (w *Watcher) Sync(username string) error {
+	select {
+	case w.syncRequests <- syncRequest{username: username, force: true}:
+		return nil
+	default:
+		return ErrSyncPending
+	}
 }
 
-func NewWatcher(logger lager.Logger, d *db.DB, httpClient *http.Client, pokemonList []*models.PokemonEntry) Watcher {
-	lastEntry := pokemonList[len(pokemonList)-1]
-	return Watcher{logger, d, httpClient, pokemonList, lastEntry.Weight}
+func (w *Watcher) PollInterval() time.Duration {
+	return w.config.PollInterval
 }
 
-func (w Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+// LastCycleCompleted is when the watcher last finished a scheduled poll, or
+// when it started if it has not finished one yet.
+func (w *Watcher) LastCycleCompleted() time.Time {
+	w.cycleLock.Lock()
+	defer w.cycleLock.Unlock()
+
+	return w.lastCycleCompleted
+}
+
+func (w *Watcher) cycleCompleted() {
+	w.cycleLock.Lock()
+	defer w.cycleLock.Unlock()
+
+	w.lastCycleCompleted = time.Now()
+}
+
+func (w *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	logger := w.logger.Session("watcher")
 	logger.Info("started")
 	defer logger.Info("complete")
 
+	w.cycleCompleted()
 	close(ready)
 
-	timer := time.NewTimer(30 * time.Second)
+	timer := time.NewTimer(w.config.PollInterval)
 
 	for {
 		select {
 		case sig := <-signals:
 			logger.Info("signaled", lager.Data{"signal": sig})
 			return nil
+		case request := <-w.syncRequests:
+			logger.Info("syncing-on-demand", lager.Data{"username": request.username})
+			err := w.distributePokemon(logger, request)
+			if err != nil {
+				logger.Error("failed-to-distribute-pokemon", err)
+			}
 		case <-timer.C:
 			logger.Info("distributing-pokemon")
-			err := w.distributePokemon(logger)
+			err := w.distributePokemon(logger, syncRequest{})
 			if err != nil {
 				logger.Error("failed-to-distribute-pokemon", err)
+			} else {
+				w.cycleCompleted()
 			}
 
-			timer = time.NewTimer(30 * time.Second)
+			timer = time.NewTimer(w.config.PollInterval)
 		}
 	}
-
-	return nil
 }
 
-func (w Watcher) distributePokemon(logger lager.Logger) error {
+func (w *Watcher) distributePokemon(logger lager.Logger, request syncRequest) error {
+	start := time.Now()
+	defer func() {
+		metrics.WatcherCycleDuration.Observe(metrics.Since(start))
+	}()
+
 	users, err := w.d.Users(logger)
 	if err != nil {
 		logger.Error("failed-to-list-users", err)
 		return err
 	}
 
+	teams, err := w.d.Teams(logger)
+	if err != nil {
+		logger.Error("failed-to-list-teams", err)
+		return err
+	}
+
+	teamsByName := map[string]*models.Team{}
+	for _, team := range teams {
+		teamsByName[team.Name] = team
+	}
+
+	now := time.Now()
+	w.schedule.Prune(users)
+
+	jobs := make(chan *models.User)
 	wg := &sync.WaitGroup{}
 
-	for _, user := range users {
+	for i := 0; i < w.config.Workers; i++ {
 		wg.Add(1)
 		go func() {
-			w.distributeForUser(logger, user)
-			wg.Done()
+			defer wg.Done()
+			for user := range jobs {
+				w.processUser(logger, user, teamsByName[user.Team], now)
+			}
 		}()
 	}
 
+	for _, user := range users {
+		if request.username != "" && user.Username != request.username {
+			continue
+		}
+		if !w.schedule.Due(user, now, request.force) {
+			continue
+		}
+		jobs <- user
+	}
+
+	close(jobs)
 	wg.Wait()
 
+	for _, team := range teams {
+		if team.Attribution != models.AttributionOwners {
+			continue
+		}
+
+		members := []*models.User{}
+		for _, user := range users {
+			if user.Team == team.Name {
+				members = append(members, user)
+			}
+		}
+
+		if request.username != "" && !containsUser(members, request.username) {
+			continue
+		}
+
+		err := w.creditOwners(logger, team, members, now)
+		if err != nil {
+			logger.Error("failed-to-credit-owners", err, lager.Data{"team": team.Name})
+		}
+	}
+
 	return nil
 }
 
-type Notification []struct {
-	Action string `json:"action"`
+func containsUser(users []*models.User, username string) bool {
+	for _, user := range users {
+		if user.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) processUser(logger lager.Logger, user *models.User, team *models.Team, now time.Time) {
+	logger = logger.Session("process-user", lager.Data{"username": user.Username})
+
+	err := w.distributeForUser(logger, user, team)
+	if err == nil {
+		w.schedule.Succeeded(user.Username, now, w.config.PollInterval)
+
+		err = w.d.RecordSyncSuccess(logger, user.Username, now)
+		if err != nil {
+			logger.Error("failed-to-record-sync-success", err)
+		}
+		return
+	}
+
+	switch err := err.(type) {
+	case tracker.UnauthorizedError:
+		metrics.SyncFailures.WithLabelValues(user.Username, "unauthorized").Inc()
+		logger.Error("tracker-token-rejected", err)
+		w.schedule.Unauthorized(user, now)
+	case tracker.RateLimitedError:
+		metrics.SyncFailures.WithLabelValues(user.Username, "rate-limited").Inc()
+		if err.RetryAfter > 0 {
+			logger.Info("rate-limited", lager.Data{"retry-after": err.RetryAfter.String()})
+			w.schedule.Defer(user.Username, now.Add(err.RetryAfter))
+			break
+		}
+		backoff := w.schedule.Failed(user.Username, now, w.config.PollInterval)
+		logger.Info("backing-off", lager.Data{"backoff": backoff.String()})
+	default:
+		metrics.SyncFailures.WithLabelValues(user.Username, "error").Inc()
+		backoff := w.schedule.Failed(user.Username, now, w.config.PollInterval)
+		logger.Info("backing-off", lager.Data{"backoff": backoff.String()})
+	}
+
+	recordErr := w.d.RecordSyncFailure(logger, user.Username, err)
+	if recordErr != nil {
+		logger.Error("failed-to-record-sync-failure", recordErr)
+	}
 }
 
-func (w Watcher) distributeForUser(logger lager.Logger, user *models.User) error {
+func (w *Watcher) distributeForUser(logger lager.Logger, user *models.User, team *models.Team) error {
 	startProcessingTime := time.Now()
-	lastProcessedAt := user.LastProcessedAt.Format(time.RFC3339)
 
-	path := fmt.Sprintf("https://www.pivotaltracker.com/services/v5/my/notifications?created_after=%s", lastProcessedAt)
-	req, err := http.NewRequest("GET", path, nil)
+	// Owners are credited from project activity instead; the user's own
+	// notifications are skipped but still marked processed.
+	if team != nil && team.Attribution == models.AttributionOwners {
+		err := w.identifyUser(logger, user)
+		if err != nil {
+			return err
+		}
+		return w.award(logger, user, team, nil, startProcessingTime)
+	}
+
+	notifications, err := w.trackerClient.Notifications(logger, user.TrackerAPIToken, user.LastProcessedAt)
 	if err != nil {
-		logger.Error("failed-to-create-request", err)
+		logger.Error("failed-to-fetch-notifications", err)
 		return err
 	}
 
-	req.Header.Add("X-TrackerToken", user.TrackerAPIToken)
+	projects := allowedProjects(user, team)
 
-	resp, err := w.httpClient.Do(req)
-	if err != nil {
-		logger.Error("failed-to-make-request", err)
-		return err
+	acceptances := []tracker.Notification{}
+	for _, notification := range notifications {
+		if notification.Action != "acceptance" {
+			continue
+		}
+		if projects != nil && !projects[notification.Project.ID] {
+			logger.Debug("skipping-acceptance-outside-projects", lager.Data{"project-id": notification.Project.ID})
+			continue
+		}
+		acceptances = append(acceptances, notification)
 	}
-	defer resp.Body.Close()
+	sort.Sort(tracker.ByCreatedAt(acceptances))
+
+	accepted := []acceptance{}
+	for _, notification := range acceptances {
+		at := notification.CreatedAt
+		if at.IsZero() {
+			at = startProcessingTime
+		}
+		accepted = append(accepted, acceptance{at: at, story: storyFor(notification.Story)})
+	}
+
+	return w.award(logger, user, team, accepted, startProcessingTime)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		err = errors.New("oh-no!")
-		logger.Error("failed-request", err)
+// identifyUser records the Tracker person behind the user's token so story
+// owners can be mapped back to registered users.
+func (w *Watcher) identifyUser(logger lager.Logger, user *models.User) error {
+	if user.TrackerPersonID != 0 {
+		return nil
+	}
+
+	person, err := w.trackerClient.Me(logger, user.TrackerAPIToken)
+	if err != nil {
+		logger.Error("failed-to-fetch-tracker-person", err)
 		return err
 	}
 
-	activity := Notification{}
-	err = json.NewDecoder(resp.Body).Decode(&activity)
+	err = w.d.SetTrackerPersonID(logger, user.Username, person.ID)
 	if err != nil {
-		logger.Error("failed-to-unmarshal-activity", err)
+		logger.Error("failed-to-record-tracker-person", err)
 		return err
 	}
 
-	for _, notification := range activity {
-		if notification.Action == "acceptance" {
-			user.Pokemon = append(user.Pokemon, w.randomPokemon())
-		}
+	user.TrackerPersonID = person.ID
+	return nil
+}
+
+type acceptance struct {
+	at    time.Time
+	story *models.Story
+}
+
+func storyFor(story tracker.Story) *models.Story {
+	if story.ID == 0 {
+		return nil
 	}
 
-	err = w.d.AddUserPokemon(logger, user.Username, user.Pokemon, startProcessingTime)
+	url := story.URL
+	if url == "" {
+		url = tracker.StoryURL(story.ID)
+	}
+
+	return &models.Story{ID: story.ID, Name: story.Name, URL: url}
+}
+
+// award grants a catch per acceptance, plus any streak bonus rolls, and
+// records processedAt as the user's last sync. Acceptances must be sorted.
+func (w *Watcher) award(logger lager.Logger, user *models.User, team *models.Team, accepted []acceptance, processedAt time.Time) error {
+	rolled, err := w.rollAward(logger, user, team, accepted, processedAt)
+	if err != nil {
+		return err
+	}
+
+	err = w.d.AddUserPokemon(logger, user.Username, rolled.Caught, rolled.Encounters, rolled.Streak, processedAt)
 	if err != nil {
 		logger.Error("failed-to-update-user", err)
 		return err
 	}
 
+	w.publish(logger, user, rolled, processedAt)
 	return nil
 }
 
-func (w Watcher) randomPokemon() string {
-	num := rand.Float64() * w.maxPokemonNumber
-	for _, entry := range w.pokemonList {
-		if num < entry.Weight {
-			str := strconv.FormatFloat(entry.Weight, 'f', -1, 64)
-			return fmt.Sprintf("%d: %s Rarity: %s", entry.Index, entry.Name, str)
+// rolledAward is an award rolled but not yet recorded, with what is
+// published once it is.
+type rolledAward struct {
+	models.Award
+	stories      []*models.Story
+	achievements []*models.Achievement
+}
+
+// rollAward advances the user's streak through the sorted acceptances and rolls
+// a Pokemon for each, plus any streak bonus rolls, without recording them.
+func (w *Watcher) rollAward(logger lager.Logger, user *models.User, team *models.Team, accepted []acceptance, processedAt time.Time) (rolledAward, error) {
+	rolled := rolledAward{Award: models.Award{
+		Username:   user.Username,
+		Caught:     []models.Pokemon{},
+		Encounters: []models.Encounter{},
+		Streak:     user.Streak,
+	}}
+	rolled.stories = []*models.Story{}
+	rolled.achievements = []*models.Achievement{}
+
+	for _, acceptance := range accepted {
+		rolled.stories = append(rolled.stories, acceptance.story)
+
+		advanced := streak.Advance(w.config.Calendar, rolled.Streak, acceptance.at)
+		bonus := streak.BonusRolls(w.config.Milestones, rolled.Streak, advanced)
+		if bonus > 0 {
+			logger.Info("streak-milestone-reached", lager.Data{"streak": advanced.Current, "bonus-rolls": bonus})
+			rolled.achievements = append(rolled.achievements, &models.Achievement{
+				Name:       fmt.Sprintf("streak-%d", advanced.Current),
+				Streak:     advanced.Current,
+				BonusRolls: bonus,
+			})
+			for i := 0; i < bonus; i++ {
+				rolled.stories = append(rolled.stories, nil)
+			}
+		}
+
+		rolled.Streak = advanced
+	}
+
+	if len(rolled.stories) > 0 {
+		table, err := w.encounterTable(logger, user, team, processedAt)
+		if err != nil {
+			return rolledAward{}, err
+		}
+
+		for range rolled.stories {
+			encountered, pokemon, err := w.randomPokemon(table, user.Username, processedAt)
+			if err != nil {
+				logger.Error("failed-to-select-pokemon", err)
+				return rolledAward{}, err
+			}
+			rolled.Encounters = append(rolled.Encounters, encountered)
+			rolled.Caught = append(rolled.Caught, pokemon)
+		}
+	}
+
+	return rolled, nil
+}
+
+// publish announces a recorded award and brings the user's streak up to
+// date.
+func (w *Watcher) publish(logger lager.Logger, user *models.User, rolled rolledAward, processedAt time.Time) {
+	user.Streak = rolled.Streak
+
+	for _, achievement := range rolled.achievements {
+		w.config.Publisher.Publish(logger, models.GameEvent{
+			Type:        models.GameEventAchievement,
+			Username:    user.Username,
+			Team:        user.Team,
+			OccurredAt:  processedAt,
+			Achievement: achievement,
+		})
+	}
+
+	for i, pokemon := range rolled.Caught {
+		metrics.CatchesAwarded.WithLabelValues(pokemon.Tier, pokemon.Pool).Inc()
+
+		w.config.Publisher.Publish(logger, models.GameEvent{
+			Type:       models.GameEventCatch,
+			Username:   user.Username,
+			Team:       user.Team,
+			OccurredAt: processedAt,
+			Catch: &models.Catch{
+				EncounterID:  rolled.Encounters[i].ID,
+				FeedSequence: rolled.Encounters[i].FeedSequence,
+				Pokemon:      pokemon,
+				Story:        rolled.stories[i],
+			},
+		})
+	}
+}
+
+// allowedProjects returns the Tracker projects whose acceptances earn the user
+// catches: their own allowlist, else their team's projects. A nil result
+// means every project counts.
+func allowedProjects(user *models.User, team *models.Team) map[int]bool {
+	ids := user.TrackerProjectIDs
+	if len(ids) == 0 && team != nil {
+		ids = team.TrackerProjectIDs
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	projects := map[int]bool{}
+	for _, id := range ids {
+		projects[id] = true
+	}
+	return projects
+}
+
+// encounterTable returns the user's pool with the boosts of any events
+// running at the given time applied. Members of a team with its own pool
+// draw from that pool, everyone else from the server-wide pool.
+func (w *Watcher) encounterTable(logger lager.Logger, user *models.User, team *models.Team, at time.Time) (*encounter.Table, error) {
+	pool := w.config.Pool
+	if team != nil && team.Pool != "" {
+		if _, ok := w.pools.Get(team.Pool); ok {
+			pool = team.Pool
+		} else {
+			logger.Info("unknown-team-pool", lager.Data{"team": team.Name, "pool": team.Pool})
 		}
 	}
 
-	panic("Whoops?")
+	table, ok := w.pools.Get(pool)
+	if !ok {
+		err := encounter.InvalidPoolError{ID: pool, Reason: "pool is not configured"}
+		logger.Error("failed-to-find-pool", err)
+		return nil, err
+	}
+
+	events, err := w.d.ActiveEvents(logger, at)
+	if err != nil {
+		logger.Error("failed-to-fetch-active-events", err)
+		return nil, err
+	}
+
+	boosts := []encounter.Boost{}
+	for _, event := range events {
+		if !event.AppliesTo(user.Team) {
+			continue
+		}
+		boosts = append(boosts, encounter.Boost{
+			Species:    event.Species,
+			Tiers:      event.Tiers,
+			Multiplier: event.Multiplier,
+		})
+	}
+
+	boosted, err := table.WithBoosts(boosts)
+	if err != nil {
+		logger.Error("failed-to-apply-event-boosts", err)
+		return nil, err
+	}
+
+	return boosted, nil
+}
+
+func (w *Watcher) roll() float64 {
+	w.randLock.Lock()
+	defer w.randLock.Unlock()
+
+	return w.random.Float64()
+}
+
+func (w *Watcher) randomPokemon(table *encounter.Table, username string, now time.Time) (models.Encounter, models.Pokemon, error) {
+	roll := w.roll()
+	entry, err := table.Select(roll)
+	if err != nil {
+		return models.Encounter{}, models.Pokemon{}, err
+	}
+
+	rolled := models.Encounter{
+		Username:     username,
+		Roll:         roll,
+		Pool:         table.Pool(),
+		PoolVersion:  table.Version(),
+		PokemonIndex: entry.Index,
+		PokemonName:  entry.Name,
+		CreatedAt:    now,
+	}
+
+	pokemon := models.Pokemon{
+		Index: entry.Index,
+		Name:  entry.Name,
+		Tier:  entry.Tier,
+		Pool:  table.Pool(),
+	}
+
+	return rolled, pokemon, nil
 }
@@ -0,0 +1,265 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/db"
+	"github.com/jfmyers9/gotta-track-em-all/logging"
+	"github.com/jfmyers9/gotta-track-em-all/metrics"
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	EventHeader     = "X-Gotta-Track-Em-All-Event"
+	DeliveryHeader  = "X-Gotta-Track-Em-All-Delivery"
+	SignatureHeader = "X-Gotta-Track-Em-All-Signature"
+
+	DefaultMaxAttempts  = 8
+	DefaultRetryDelay   = 30 * time.Second
+	DefaultPollInterval = 10 * time.Second
+	MaxRetryDelay       = time.Hour
+
+	batchSize = 50
+)
+
+type Config struct {
+	MaxAttempts  int
+	RetryDelay   time.Duration
+	PollInterval time.Duration
+}
+
+// Dispatcher queues events for every subscribed webhook and delivers them in
+// the background. Failed deliveries are retried with exponential backoff
+// until MaxAttempts, after which they are left dead for an operator to
+// inspect or retry.
+type Dispatcher struct {
+	logger     lager.Logger
+	d          *db.DB
+	httpClient *http.Client
+	config     Config
+	wake       chan struct{}
+}
+
+func NewDispatcher(logger lager.Logger, d *db.DB, httpClient *http.Client, config Config) *Dispatcher {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+
+	return &Dispatcher{
+		logger:     logger,
+		d:          d,
+		httpClient: httpClient,
+		config:     config,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Sign returns the signature header value for body: the hex HMAC-SHA256 of
+// the body keyed with the webhook's secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) Publish(logger lager.Logger, event models.GameEvent) {
+	logger = logger.Session("publish-webhooks", lager.Data{"type": event.Type})
+
+	webhooks, err := d.d.Webhooks(logger)
+	if err != nil {
+		logger.Error("failed-to-list-webhooks", err)
+		return
+	}
+
+	body, err := json.Marshal(NewPayload(event))
+	if err != nil {
+		logger.Error("failed-marshalling-payload", err)
+		return
+	}
+
+	now := time.Now()
+	deliveries := []models.WebhookDelivery{}
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     event.Type,
+			Payload:       string(body),
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	err = d.d.EnqueueWebhookDeliveries(logger, deliveries)
+	if err != nil {
+		logger.Error("failed-to-enqueue-deliveries", err)
+		return
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := d.logger.Session("webhook-dispatcher")
+	logger.Info("started")
+	defer logger.Info("complete")
+
+	close(ready)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case sig := <-signals:
+			logger.Info("signaled", lager.Data{"signal": sig})
+			return nil
+		case <-d.wake:
+			d.deliverDue(logger)
+		case <-ticker.C:
+			d.deliverDue(logger)
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue(logger lager.Logger) {
+	now := time.Now()
+
+	deliveries, err := d.d.DueWebhookDeliveries(logger, now, batchSize)
+	if err != nil {
+		logger.Error("failed-to-fetch-due-deliveries", err)
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	webhooks, err := d.d.Webhooks(logger)
+	if err != nil {
+		logger.Error("failed-to-list-webhooks", err)
+		return
+	}
+
+	byID := map[int]models.Webhook{}
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+
+	for _, delivery := range deliveries {
+		webhook, ok := byID[delivery.WebhookID]
+		if !ok {
+			continue
+		}
+
+		delivery = d.attempt(logger, webhook, delivery, now)
+
+		err := d.d.UpdateWebhookDelivery(logger, delivery)
+		if err != nil {
+			logger.Error("failed-to-record-delivery", err, lager.Data{"delivery-id": delivery.ID})
+		}
+	}
+
+	// A full batch means more may be due; go again rather than waiting for
+	// the next tick.
+	if len(deliveries) == batchSize {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(logger lager.Logger, webhook models.Webhook, delivery models.WebhookDelivery, now time.Time) models.WebhookDelivery {
+	logger = logger.Session("deliver", lager.Data{"webhook-id": webhook.ID, "delivery-id": delivery.ID})
+
+	delivery.Attempts++
+	statusCode, err := d.post(webhook, delivery)
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = now
+		metrics.WebhookDeliveries.WithLabelValues("delivered").Inc()
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= d.config.MaxAttempts {
+		logger.Error("delivery-dead", err, lager.Data{"attempts": delivery.Attempts})
+		delivery.Status = models.DeliveryDead
+		metrics.WebhookDeliveries.WithLabelValues("dead").Inc()
+		return delivery
+	}
+
+	backoff := d.config.RetryDelay
+	for i := 1; i < delivery.Attempts && backoff < MaxRetryDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxRetryDelay {
+		backoff = MaxRetryDelay
+	}
+
+	logger.Info("delivery-failed", lager.Data{"error": err.Error(), "attempts": delivery.Attempts, "retry-in": backoff.String()})
+	delivery.NextAttemptAt = now.Add(backoff)
+	metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+	return delivery
+}
+
+func (d *Dispatcher) post(webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	// Errors leave out the URL, which may carry the subscriber's credentials
+	// and is both logged and stored with the delivery.
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, logging.RedactURLError(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, logging.RedactURLError(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
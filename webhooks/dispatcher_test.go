@@ -0,0 +1,195 @@
+package webhooks
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+	"github.com/pivotal-golang/lager"
+)
+
+// receiver is a webhook endpoint that answers with the given status codes in
+// turn, repeating the last, and records what it was sent.
+type receiver struct {
+	server   *httptest.Server
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+
+		status := r.statuses[len(r.statuses)-1]
+		if len(r.requests) <= len(r.statuses) {
+			status = r.statuses[len(r.requests)-1]
+		}
+		w.WriteHeader(status)
+	}))
+	return r
+}
+
+func newTestDispatcher(config Config) *Dispatcher {
+	return NewDispatcher(lager.NewLogger("test"), nil, http.DefaultClient, config)
+}
+
+func newDelivery() models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:        42,
+		WebhookID: 7,
+		EventType: models.GameEventCatch,
+		Payload:   `{"type":"catch","username":"ash"}`,
+		Status:    models.DeliveryPending,
+	}
+}
+
+func TestAttemptSignsPayload(t *testing.T) {
+	r := newReceiver(http.StatusOK)
+	defer r.server.Close()
+
+	webhook := models.Webhook{ID: 7, URL: r.server.URL, Secret: "pikachu"}
+	now := time.Unix(1466467200, 0)
+
+	delivery := newTestDispatcher(Config{}).attempt(lager.NewLogger("test"), webhook, newDelivery(), now)
+
+	if len(r.requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(r.requests))
+	}
+	req, body := r.requests[0], r.bodies[0]
+
+	if string(body) != newDelivery().Payload {
+		t.Errorf("body: got %s, want %s", body, newDelivery().Payload)
+	}
+	if got, want := req.Header.Get(SignatureHeader), Sign(webhook.Secret, body); got != want {
+		t.Errorf("signature: got %q, want %q", got, want)
+	}
+	if got := req.Header.Get(SignatureHeader); got == Sign("wrong", body) {
+		t.Errorf("signature %q does not depend on the secret", got)
+	}
+	if got := req.Header.Get(EventHeader); got != models.GameEventCatch {
+		t.Errorf("event header: got %q, want %q", got, models.GameEventCatch)
+	}
+	if got := req.Header.Get(DeliveryHeader); got != strconv.Itoa(delivery.ID) {
+		t.Errorf("delivery header: got %q, want %d", got, delivery.ID)
+	}
+
+	if delivery.Status != models.DeliveryDelivered || delivery.Attempts != 1 || !delivery.DeliveredAt.Equal(now) {
+		t.Errorf("got status %q after %d attempts delivered at %s, want delivered after 1 at %s", delivery.Status, delivery.Attempts, delivery.DeliveredAt, now)
+	}
+}
+
+func TestAttemptRetriesAfterServerError(t *testing.T) {
+	r := newReceiver(http.StatusInternalServerError, http.StatusOK)
+	defer r.server.Close()
+
+	webhook := models.Webhook{ID: 7, URL: r.server.URL, Secret: "pikachu"}
+	dispatcher := newTestDispatcher(Config{MaxAttempts: 3, RetryDelay: time.Minute})
+	now := time.Unix(1466467200, 0)
+
+	delivery := dispatcher.attempt(lager.NewLogger("test"), webhook, newDelivery(), now)
+	if delivery.Status != models.DeliveryPending {
+		t.Fatalf("status after a 500: got %q, want %q", delivery.Status, models.DeliveryPending)
+	}
+	if delivery.LastStatusCode != http.StatusInternalServerError || delivery.LastError == "" {
+		t.Errorf("got status code %d and error %q, want the 500 recorded", delivery.LastStatusCode, delivery.LastError)
+	}
+	if want := now.Add(time.Minute); !delivery.NextAttemptAt.Equal(want) {
+		t.Errorf("next attempt: got %s, want %s", delivery.NextAttemptAt, want)
+	}
+
+	now = delivery.NextAttemptAt
+	delivery = dispatcher.attempt(lager.NewLogger("test"), webhook, delivery, now)
+	if delivery.Status != models.DeliveryDelivered || delivery.Attempts != 2 {
+		t.Errorf("retry: got status %q after %d attempts, want delivered after 2", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastError != "" {
+		t.Errorf("retry: error %q was not cleared", delivery.LastError)
+	}
+	if len(r.requests) != 2 {
+		t.Errorf("got %d requests, want 2", len(r.requests))
+	}
+}
+
+func TestAttemptMarksDeadAfterMaxAttempts(t *testing.T) {
+	r := newReceiver(http.StatusInternalServerError)
+	defer r.server.Close()
+
+	webhook := models.Webhook{ID: 7, URL: r.server.URL, Secret: "pikachu"}
+	dispatcher := newTestDispatcher(Config{MaxAttempts: 3, RetryDelay: time.Minute})
+	now := time.Unix(1466467200, 0)
+
+	delivery := newDelivery()
+	for i := 1; i <= 3; i++ {
+		delivery = dispatcher.attempt(lager.NewLogger("test"), webhook, delivery, now)
+		if i < 3 && delivery.Status != models.DeliveryPending {
+			t.Fatalf("attempt %d: got status %q, want %q", i, delivery.Status, models.DeliveryPending)
+		}
+		now = delivery.NextAttemptAt
+	}
+
+	if delivery.Status != models.DeliveryDead || delivery.Attempts != 3 {
+		t.Errorf("got status %q after %d attempts, want dead after 3", delivery.Status, delivery.Attempts)
+	}
+	if len(r.requests) != 3 {
+		t.Errorf("got %d requests, want 3", len(r.requests))
+	}
+}
+
+func TestAttemptCapsBackoff(t *testing.T) {
+	r := newReceiver(http.StatusInternalServerError)
+	defer r.server.Close()
+
+	webhook := models.Webhook{ID: 7, URL: r.server.URL, Secret: "pikachu"}
+	dispatcher := newTestDispatcher(Config{MaxAttempts: 100, RetryDelay: 10 * time.Minute})
+	now := time.Unix(1466467200, 0)
+
+	expected := []time.Duration{
+		10 * time.Minute,
+		20 * time.Minute,
+		40 * time.Minute,
+		MaxRetryDelay,
+		MaxRetryDelay,
+	}
+
+	delivery := newDelivery()
+	for i := 0; i < 70; i++ {
+		delivery = dispatcher.attempt(lager.NewLogger("test"), webhook, delivery, now)
+
+		want := MaxRetryDelay
+		if i < len(expected) {
+			want = expected[i]
+		}
+		if got := delivery.NextAttemptAt.Sub(now); got != want {
+			t.Fatalf("attempt %d: backoff %s, want %s", delivery.Attempts, got, want)
+		}
+		now = delivery.NextAttemptAt
+	}
+}
+
+func TestAttemptLeavesURLOutOfTransportErrors(t *testing.T) {
+	r := newReceiver(http.StatusOK)
+	webhook := models.Webhook{ID: 7, URL: r.server.URL + "/hooks/T000/B000/s3cr3t", Secret: "pikachu"}
+	r.server.Close()
+
+	dispatcher := newTestDispatcher(Config{MaxAttempts: 3, RetryDelay: time.Minute})
+	delivery := dispatcher.attempt(lager.NewLogger("test"), webhook, newDelivery(), time.Unix(1466467200, 0))
+
+	if delivery.Status != models.DeliveryPending || delivery.LastStatusCode != 0 {
+		t.Fatalf("got status %q with status code %d, want a pending retry after a transport failure", delivery.Status, delivery.LastStatusCode)
+	}
+	if delivery.LastError == "" {
+		t.Fatal("the transport failure was not recorded")
+	}
+	if strings.Contains(delivery.LastError, "s3cr3t") || strings.Contains(delivery.LastError, webhook.URL) {
+		t.Errorf("last error %q contains the webhook URL", delivery.LastError)
+	}
+}
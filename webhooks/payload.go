@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/jfmyers9/gotta-track-em-all/models"
+)
+
+// Payload is the JSON body POSTed to webhooks. Its shape is a public
+// contract, so it is kept apart from the models.
+type Payload struct {
+	Type        string              `json:"type"`
+	Username    string              `json:"username"`
+	Team        string              `json:"team,omitempty"`
+	OccurredAt  time.Time           `json:"occurred_at"`
+	Catch       *CatchPayload       `json:"catch,omitempty"`
+	Achievement *AchievementPayload `json:"achievement,omitempty"`
+	Trade       *TradePayload       `json:"trade,omitempty"`
+}
+
+type CatchPayload struct {
+	EncounterID int           `json:"encounter_id"`
+	Pokemon     PokemonInfo   `json:"pokemon"`
+	Story       *StoryPayload `json:"story,omitempty"`
+}
+
+type PokemonInfo struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Tier  string `json:"tier"`
+	Pool  string `json:"pool"`
+}
+
+type StoryPayload struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type AchievementPayload struct {
+	Name       string `json:"name"`
+	Streak     int    `json:"streak"`
+	BonusRolls int    `json:"bonus_rolls"`
+}
+
+type TradePayload struct {
+	ID        int         `json:"id"`
+	Proposer  string      `json:"proposer"`
+	Recipient string      `json:"recipient"`
+	Offered   PokemonInfo `json:"offered"`
+	Requested PokemonInfo `json:"requested"`
+}
+
+func NewPayload(event models.GameEvent) Payload {
+	payload := Payload{
+		Type:       event.Type,
+		Username:   event.Username,
+		Team:       event.Team,
+		OccurredAt: event.OccurredAt,
+	}
+
+	if event.Catch != nil {
+		payload.Catch = &CatchPayload{
+			EncounterID: event.Catch.EncounterID,
+			Pokemon:     newPokemonInfo(event.Catch.Pokemon),
+		}
+		if story := event.Catch.Story; story != nil {
+			payload.Catch.Story = &StoryPayload{ID: story.ID, Name: story.Name, URL: story.URL}
+		}
+	}
+
+	if event.Achievement != nil {
+		payload.Achievement = &AchievementPayload{
+			Name:       event.Achievement.Name,
+			Streak:     event.Achievement.Streak,
+			BonusRolls: event.Achievement.BonusRolls,
+		}
+	}
+
+	if event.Trade != nil {
+		payload.Trade = &TradePayload{
+			ID:        event.Trade.ID,
+			Proposer:  event.Trade.Proposer,
+			Recipient: event.Trade.Recipient,
+			Offered:   newPokemonInfo(event.Trade.Offered),
+			Requested: newPokemonInfo(event.Trade.Requested),
+		}
+	}
+
+	return payload
+}
+
+func newPokemonInfo(pokemon models.Pokemon) PokemonInfo {
+	return PokemonInfo{
+		Index: pokemon.Index,
+		Name:  pokemon.Name,
+		Tier:  pokemon.Tier,
+		Pool:  pokemon.Pool,
+	}
+}